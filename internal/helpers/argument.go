@@ -0,0 +1,29 @@
+// Copyright (c) Robin Breathe and contributors
+// SPDX-License-Identifier: MPL-2.0
+
+package helpers
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// Argument is a map to be merged, along with its position in the function's
+// argument list so that errors can refer to it.
+type Argument struct {
+	Position int64
+	// Element is the index of the map within a list argument, or -1 if the
+	// argument is the map itself.
+	Element int64
+	Value   types.Dynamic
+}
+
+// String names the argument as in error messages, numbering arguments from 1
+// and list elements from 0, as they are indexed in Terraform.
+func (a Argument) String() string {
+	if a.Element < 0 {
+		return fmt.Sprintf("argument %d", a.Position+1)
+	}
+	return fmt.Sprintf("argument %d element %d", a.Position+1, a.Element)
+}
@@ -0,0 +1,105 @@
+// Copyright (c) Robin Breathe and contributors
+// SPDX-License-Identifier: MPL-2.0
+
+package helpers
+
+import (
+	"context"
+	"maps"
+	"slices"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-framework/types/basetypes"
+)
+
+// NewObject builds an object from its attributes.
+func NewObject(ctx context.Context, vm map[string]attr.Value) (attr.Value, diag.Diagnostics) {
+	tm := make(map[string]attr.Type, len(vm))
+	for k, v := range vm {
+		tm[k] = v.Type(ctx)
+	}
+	return types.ObjectValue(tm, vm)
+}
+
+// NewTuple builds a tuple from its elements.
+func NewTuple(ctx context.Context, vl []attr.Value) (attr.Value, diag.Diagnostics) {
+	tl := make([]attr.Type, len(vl))
+	for i, v := range vl {
+		tl[i] = v.Type(ctx)
+	}
+	return types.TupleValue(tl, vl)
+}
+
+// NewMapOrObject builds a map, or an object if the elements don't share a
+// type. An empty map has elemType, if given, and is otherwise an object.
+func NewMapOrObject(ctx context.Context, vm map[string]attr.Value, elemType attr.Type) (attr.Value, diag.Diagnostics) {
+	t, ok := elementType(ctx, slices.Collect(maps.Values(vm)), elemType)
+	if !ok {
+		return NewObject(ctx, vm)
+	}
+	return types.MapValue(t, vm)
+}
+
+// NewListOrTuple builds a list, or a tuple if the elements don't share a
+// type. An empty list has elemType, if given, and is otherwise a tuple.
+func NewListOrTuple(ctx context.Context, vl []attr.Value, elemType attr.Type) (attr.Value, diag.Diagnostics) {
+	t, ok := elementType(ctx, vl, elemType)
+	if !ok {
+		return NewTuple(ctx, vl)
+	}
+	return types.ListValue(t, vl)
+}
+
+// NewSetOrTuple builds a set, dropping any duplicate elements, or a tuple if
+// the elements don't share a type. An empty set has elemType, if given, and
+// is otherwise a tuple.
+func NewSetOrTuple(ctx context.Context, vl []attr.Value, elemType attr.Type) (attr.Value, diag.Diagnostics) {
+	t, ok := elementType(ctx, vl, elemType)
+	if !ok {
+		return NewTuple(ctx, vl)
+	}
+
+	unique := make([]attr.Value, 0, len(vl))
+	seen := make(map[string]bool, len(vl))
+	for _, v := range vl {
+		if key := Key(ctx, v); !seen[key] {
+			seen[key] = true
+			unique = append(unique, v)
+		}
+	}
+
+	return types.SetValue(t, unique)
+}
+
+// elementType returns the type shared by the elements of a collection, or
+// elemType if there are none.
+func elementType(ctx context.Context, values []attr.Value, elemType attr.Type) (attr.Type, bool) {
+	if len(values) == 0 && elemType != nil {
+		_, dynamic := elemType.(basetypes.DynamicType)
+		return elemType, !dynamic
+	}
+	return commonType(ctx, values)
+}
+
+// commonType returns the type shared by all values, which must be neither
+// empty nor dynamic.
+func commonType(ctx context.Context, values []attr.Value) (attr.Type, bool) {
+	if len(values) == 0 {
+		return nil, false
+	}
+
+	t := values[0].Type(ctx)
+	if _, ok := t.(basetypes.DynamicType); ok {
+		return nil, false
+	}
+
+	for _, v := range values[1:] {
+		if !v.Type(ctx).Equal(t) {
+			return nil, false
+		}
+	}
+
+	return t, true
+}
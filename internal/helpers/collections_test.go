@@ -0,0 +1,92 @@
+// Copyright (c) Robin Breathe and contributors
+// SPDX-License-Identifier: MPL-2.0
+
+package helpers
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewCollections(t *testing.T) {
+	words := []attr.Value{types.StringValue("a"), types.StringValue("b"), types.StringValue("a")}
+	mixed := []attr.Value{types.StringValue("a"), types.BoolValue(true)}
+
+	tests := []struct {
+		name     string
+		build    func() (attr.Value, diag.Diagnostics)
+		expected attr.Value
+	}{
+		{
+			name: "map of strings",
+			build: func() (attr.Value, diag.Diagnostics) {
+				return NewMapOrObject(t.Context(), map[string]attr.Value{"a": words[0]}, nil)
+			},
+			expected: types.MapValueMust(types.StringType, map[string]attr.Value{"a": words[0]}),
+		},
+		{
+			name: "map of mixed types",
+			build: func() (attr.Value, diag.Diagnostics) {
+				return NewMapOrObject(t.Context(), map[string]attr.Value{"a": mixed[0], "b": mixed[1]}, types.StringType)
+			},
+			expected: types.ObjectValueMust(
+				map[string]attr.Type{"a": types.StringType, "b": types.BoolType},
+				map[string]attr.Value{"a": mixed[0], "b": mixed[1]},
+			),
+		},
+		{
+			name: "empty map with element type",
+			build: func() (attr.Value, diag.Diagnostics) {
+				return NewMapOrObject(t.Context(), map[string]attr.Value{}, types.StringType)
+			},
+			expected: types.MapValueMust(types.StringType, map[string]attr.Value{}),
+		},
+		{
+			name: "empty map without element type",
+			build: func() (attr.Value, diag.Diagnostics) {
+				return NewMapOrObject(t.Context(), map[string]attr.Value{}, nil)
+			},
+			expected: types.ObjectValueMust(map[string]attr.Type{}, map[string]attr.Value{}),
+		},
+		{
+			name: "empty list with element type",
+			build: func() (attr.Value, diag.Diagnostics) {
+				return NewListOrTuple(t.Context(), []attr.Value{}, types.NumberType)
+			},
+			expected: types.ListValueMust(types.NumberType, []attr.Value{}),
+		},
+		{
+			name: "empty list with dynamic element type",
+			build: func() (attr.Value, diag.Diagnostics) {
+				return NewListOrTuple(t.Context(), []attr.Value{}, types.DynamicType)
+			},
+			expected: types.TupleValueMust([]attr.Type{}, []attr.Value{}),
+		},
+		{
+			name: "list of mixed types",
+			build: func() (attr.Value, diag.Diagnostics) {
+				return NewListOrTuple(t.Context(), mixed, nil)
+			},
+			expected: types.TupleValueMust([]attr.Type{types.StringType, types.BoolType}, mixed),
+		},
+		{
+			name: "set with duplicates",
+			build: func() (attr.Value, diag.Diagnostics) {
+				return NewSetOrTuple(t.Context(), words, nil)
+			},
+			expected: types.SetValueMust(types.StringType, words[:2]),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			value, diags := tt.build()
+			assert.False(t, diags.HasError())
+			assert.Equal(t, tt.expected, value)
+		})
+	}
+}
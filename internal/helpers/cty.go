@@ -0,0 +1,323 @@
+// Copyright (c) Robin Breathe and contributors
+// SPDX-License-Identifier: MPL-2.0
+
+package helpers
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-framework/types/basetypes"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// ToCtyValue converts a Terraform value to the cty value Terraform itself
+// would use, so that it can be converted with Terraform's own rules.
+func ToCtyValue(ctx context.Context, v attr.Value) (cty.Value, error) {
+	if dv, ok := v.(basetypes.DynamicValue); ok && !dv.IsNull() && !dv.IsUnknown() {
+		return ToCtyValue(ctx, dv.UnderlyingValue())
+	}
+
+	ty, err := ToCtyType(v.Type(ctx))
+	if err != nil {
+		return cty.NilVal, err
+	}
+	if v.IsNull() {
+		return cty.NullVal(ty), nil
+	}
+	if v.IsUnknown() {
+		return cty.UnknownVal(ty), nil
+	}
+
+	switch vv := v.(type) {
+	case basetypes.StringValue:
+		return cty.StringVal(vv.ValueString()), nil
+
+	case basetypes.NumberValue:
+		return cty.NumberVal(vv.ValueBigFloat()), nil
+
+	case basetypes.BoolValue:
+		return cty.BoolVal(vv.ValueBool()), nil
+
+	case basetypes.ObjectValue:
+		attrs, err := toCtyValueMap(ctx, vv.Attributes())
+		if err != nil {
+			return cty.NilVal, err
+		}
+		return cty.ObjectVal(attrs), nil
+
+	case basetypes.MapValue:
+		elems, err := toCtyValueMap(ctx, vv.Elements())
+		if err != nil || len(elems) == 0 {
+			return cty.MapValEmpty(ty.ElementType()), err
+		}
+		return cty.MapVal(elems), nil
+
+	case basetypes.TupleValue:
+		elems, err := toCtyValues(ctx, vv.Elements())
+		if err != nil {
+			return cty.NilVal, err
+		}
+		return cty.TupleVal(elems), nil
+
+	case basetypes.ListValue:
+		elems, err := toCtyValues(ctx, vv.Elements())
+		if err != nil || len(elems) == 0 {
+			return cty.ListValEmpty(ty.ElementType()), err
+		}
+		return cty.ListVal(elems), nil
+
+	case basetypes.SetValue:
+		elems, err := toCtyValues(ctx, vv.Elements())
+		if err != nil || len(elems) == 0 {
+			return cty.SetValEmpty(ty.ElementType()), err
+		}
+		return cty.SetVal(elems), nil
+
+	default:
+		return cty.NilVal, fmt.Errorf("tried to convert unsupported type: %T: %v", v, vv)
+	}
+}
+
+func toCtyValueMap(ctx context.Context, m map[string]attr.Value) (map[string]cty.Value, error) {
+	result := make(map[string]cty.Value, len(m))
+	for k, v := range m {
+		cv, err := ToCtyValue(ctx, v)
+		if err != nil {
+			return nil, err
+		}
+		result[k] = cv
+	}
+	return result, nil
+}
+
+func toCtyValues(ctx context.Context, l []attr.Value) ([]cty.Value, error) {
+	result := make([]cty.Value, len(l))
+	for i, v := range l {
+		cv, err := ToCtyValue(ctx, v)
+		if err != nil {
+			return nil, err
+		}
+		result[i] = cv
+	}
+	return result, nil
+}
+
+// ToCtyType converts a Terraform type to the equivalent cty type.
+func ToCtyType(t attr.Type) (cty.Type, error) {
+	switch tt := t.(type) {
+	case basetypes.StringType:
+		return cty.String, nil
+
+	case basetypes.NumberType:
+		return cty.Number, nil
+
+	case basetypes.BoolType:
+		return cty.Bool, nil
+
+	case basetypes.DynamicType:
+		return cty.DynamicPseudoType, nil
+
+	case basetypes.ObjectType:
+		attrTypes := make(map[string]cty.Type, len(tt.AttrTypes))
+		for k, at := range tt.AttrTypes {
+			ct, err := ToCtyType(at)
+			if err != nil {
+				return cty.NilType, err
+			}
+			attrTypes[k] = ct
+		}
+		return cty.Object(attrTypes), nil
+
+	case basetypes.TupleType:
+		elemTypes := make([]cty.Type, len(tt.ElemTypes))
+		for i, et := range tt.ElemTypes {
+			ct, err := ToCtyType(et)
+			if err != nil {
+				return cty.NilType, err
+			}
+			elemTypes[i] = ct
+		}
+		return cty.Tuple(elemTypes), nil
+
+	case basetypes.MapType:
+		et, err := ToCtyType(tt.ElemType)
+		return cty.Map(et), err
+
+	case basetypes.ListType:
+		et, err := ToCtyType(tt.ElemType)
+		return cty.List(et), err
+
+	case basetypes.SetType:
+		et, err := ToCtyType(tt.ElemType)
+		return cty.Set(et), err
+
+	default:
+		return cty.NilType, fmt.Errorf("tried to convert unsupported type: %T", t)
+	}
+}
+
+// FromCtyValue converts a cty value back to a Terraform value.
+func FromCtyValue(ctx context.Context, v cty.Value) (attr.Value, error) {
+	ty := v.Type()
+
+	if v.IsNull() || !v.IsKnown() {
+		t, err := FromCtyType(ty)
+		if err != nil {
+			return nil, err
+		}
+		if v.IsNull() {
+			return NullOf(t), nil
+		}
+		return UnknownOf(t), nil
+	}
+
+	switch {
+	case ty == cty.String:
+		return types.StringValue(v.AsString()), nil
+
+	case ty == cty.Number:
+		return types.NumberValue(v.AsBigFloat()), nil
+
+	case ty == cty.Bool:
+		return types.BoolValue(v.True()), nil
+
+	case ty.IsObjectType():
+		attrs := make(map[string]attr.Value, len(ty.AttributeTypes()))
+		attrTypes := make(map[string]attr.Type, len(ty.AttributeTypes()))
+		for k, av := range v.AsValueMap() {
+			value, err := FromCtyValue(ctx, av)
+			if err != nil {
+				return nil, err
+			}
+			attrs[k] = value
+			attrTypes[k] = value.Type(ctx)
+		}
+		value, diags := types.ObjectValue(attrTypes, attrs)
+		return value, diagsError(diags)
+
+	case ty.IsTupleType():
+		elems, err := fromCtyValues(ctx, v)
+		if err != nil {
+			return nil, err
+		}
+		elemTypes := make([]attr.Type, len(elems))
+		for i, elem := range elems {
+			elemTypes[i] = elem.Type(ctx)
+		}
+		value, diags := types.TupleValue(elemTypes, elems)
+		return value, diagsError(diags)
+
+	case ty.IsMapType():
+		et, err := FromCtyType(ty.ElementType())
+		if err != nil {
+			return nil, err
+		}
+		elems := make(map[string]attr.Value, v.LengthInt())
+		for k, ev := range v.AsValueMap() {
+			if elems[k], err = FromCtyValue(ctx, ev); err != nil {
+				return nil, err
+			}
+		}
+		value, diags := types.MapValue(et, elems)
+		return value, diagsError(diags)
+
+	case ty.IsListType() || ty.IsSetType():
+		et, err := FromCtyType(ty.ElementType())
+		if err != nil {
+			return nil, err
+		}
+		elems, err := fromCtyValues(ctx, v)
+		if err != nil {
+			return nil, err
+		}
+		if ty.IsSetType() {
+			value, diags := types.SetValue(et, elems)
+			return value, diagsError(diags)
+		}
+		value, diags := types.ListValue(et, elems)
+		return value, diagsError(diags)
+
+	default:
+		return nil, fmt.Errorf("tried to convert unsupported type: %s", ty.FriendlyName())
+	}
+}
+
+func fromCtyValues(ctx context.Context, v cty.Value) ([]attr.Value, error) {
+	elems := make([]attr.Value, 0, v.LengthInt())
+	for it := v.ElementIterator(); it.Next(); {
+		_, ev := it.Element()
+		elem, err := FromCtyValue(ctx, ev)
+		if err != nil {
+			return nil, err
+		}
+		elems = append(elems, elem)
+	}
+	return elems, nil
+}
+
+// diagsError returns the first error among diags, if any.
+func diagsError(diags diag.Diagnostics) error {
+	if !diags.HasError() {
+		return nil
+	}
+	d := diags.Errors()[0]
+	return fmt.Errorf("%s: %s", d.Summary(), d.Detail())
+}
+
+// FromCtyType converts a cty type back to a Terraform type.
+func FromCtyType(ty cty.Type) (attr.Type, error) {
+	switch {
+	case ty == cty.String:
+		return types.StringType, nil
+
+	case ty == cty.Number:
+		return types.NumberType, nil
+
+	case ty == cty.Bool:
+		return types.BoolType, nil
+
+	case ty == cty.DynamicPseudoType:
+		return types.DynamicType, nil
+
+	case ty.IsObjectType():
+		attrTypes := make(map[string]attr.Type, len(ty.AttributeTypes()))
+		for k, at := range ty.AttributeTypes() {
+			t, err := FromCtyType(at)
+			if err != nil {
+				return nil, err
+			}
+			attrTypes[k] = t
+		}
+		return types.ObjectType{AttrTypes: attrTypes}, nil
+
+	case ty.IsTupleType():
+		elemTypes := make([]attr.Type, len(ty.TupleElementTypes()))
+		for i, et := range ty.TupleElementTypes() {
+			t, err := FromCtyType(et)
+			if err != nil {
+				return nil, err
+			}
+			elemTypes[i] = t
+		}
+		return types.TupleType{ElemTypes: elemTypes}, nil
+
+	case ty.IsMapType():
+		et, err := FromCtyType(ty.ElementType())
+		return types.MapType{ElemType: et}, err
+
+	case ty.IsListType():
+		et, err := FromCtyType(ty.ElementType())
+		return types.ListType{ElemType: et}, err
+
+	case ty.IsSetType():
+		et, err := FromCtyType(ty.ElementType())
+		return types.SetType{ElemType: et}, err
+
+	default:
+		return nil, fmt.Errorf("tried to convert unsupported type: %s", ty.FriendlyName())
+	}
+}
@@ -0,0 +1,96 @@
+// Copyright (c) Robin Breathe and contributors
+// SPDX-License-Identifier: MPL-2.0
+
+package helpers
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/zclconf/go-cty/cty"
+)
+
+func TestCtyValue_RoundTrip(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    attr.Value
+		expected cty.Value
+	}{
+		{
+			name:     "string value",
+			input:    types.StringValue("test"),
+			expected: cty.StringVal("test"),
+		},
+		{
+			name:     "number value",
+			input:    types.NumberValue(new(big.Float).SetInt64(1234567890123456789)),
+			expected: cty.NumberVal(new(big.Float).SetInt64(1234567890123456789)),
+		},
+		{
+			name:     "typed null",
+			input:    types.StringNull(),
+			expected: cty.NullVal(cty.String),
+		},
+		{
+			name:     "typed unknown",
+			input:    types.ListUnknown(types.BoolType),
+			expected: cty.UnknownVal(cty.List(cty.Bool)),
+		},
+		{
+			name: "object value",
+			input: types.ObjectValueMust(
+				map[string]attr.Type{"key1": types.StringType, "key2": types.BoolType},
+				map[string]attr.Value{"key1": types.StringValue("a"), "key2": types.BoolValue(true)},
+			),
+			expected: cty.ObjectVal(map[string]cty.Value{"key1": cty.StringVal("a"), "key2": cty.True}),
+		},
+		{
+			name:     "map value",
+			input:    types.MapValueMust(types.StringType, map[string]attr.Value{"key": types.StringValue("a")}),
+			expected: cty.MapVal(map[string]cty.Value{"key": cty.StringVal("a")}),
+		},
+		{
+			name:     "empty list value",
+			input:    types.ListValueMust(types.StringType, []attr.Value{}),
+			expected: cty.ListValEmpty(cty.String),
+		},
+		{
+			name:     "set value",
+			input:    types.SetValueMust(types.NumberType, []attr.Value{types.NumberValue(big.NewFloat(1))}),
+			expected: cty.SetVal([]cty.Value{cty.NumberIntVal(1)}),
+		},
+		{
+			name: "tuple value",
+			input: types.TupleValueMust(
+				[]attr.Type{types.StringType, types.NumberType},
+				[]attr.Value{types.StringValue("a"), types.NumberValue(big.NewFloat(1))},
+			),
+			expected: cty.TupleVal([]cty.Value{cty.StringVal("a"), cty.NumberIntVal(1)}),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cv, err := ToCtyValue(t.Context(), tt.input)
+			assert.NoError(t, err)
+			assert.True(t, cv.RawEquals(tt.expected), "got %#v", cv)
+
+			v, err := FromCtyValue(t.Context(), cv)
+			assert.NoError(t, err)
+			assert.True(t, v.Equal(tt.input), "got %s", v)
+		})
+	}
+}
+
+func TestToCtyValue_Dynamic(t *testing.T) {
+	cv, err := ToCtyValue(t.Context(), types.DynamicValue(types.StringValue("test")))
+	assert.NoError(t, err)
+	assert.True(t, cv.RawEquals(cty.StringVal("test")))
+
+	cv, err = ToCtyValue(t.Context(), types.DynamicNull())
+	assert.NoError(t, err)
+	assert.True(t, cv.RawEquals(cty.NullVal(cty.DynamicPseudoType)))
+}
@@ -1,80 +0,0 @@
-// Copyright (c) HashiCorp, Inc.
-// SPDX-License-Identifier: MPL-2.0
-
-// Originally copied from https://github.com/hashicorp/terraform-provider-kubernetes/blob/main/internal/framework/provider/functions/encode.go
-
-package helpers
-
-import (
-	"context"
-	"fmt"
-	"math/big"
-
-	"github.com/hashicorp/terraform-plugin-framework/attr"
-	"github.com/hashicorp/terraform-plugin-framework/diag"
-	"github.com/hashicorp/terraform-plugin-framework/types"
-)
-
-func DecodeMapping(ctx context.Context, m map[string]any) (attr.Value, diag.Diagnostics) {
-	vm := make(map[string]attr.Value, len(m))
-	tm := make(map[string]attr.Type, len(m))
-
-	for k, v := range m {
-		vv, diags := DecodeScalar(ctx, v)
-		if diags.HasError() {
-			return nil, diags
-		}
-
-		vm[k] = vv
-		tm[k] = vv.Type(ctx)
-	}
-
-	return types.ObjectValue(tm, vm)
-}
-
-func DecodeSequence(ctx context.Context, s []any) (attr.Value, diag.Diagnostics) {
-	vl := make([]attr.Value, len(s))
-	tl := make([]attr.Type, len(s))
-
-	for i, v := range s {
-		vv, diags := DecodeScalar(ctx, v)
-		if diags.HasError() {
-			return nil, diags
-		}
-
-		vl[i] = vv
-		tl[i] = vv.Type(ctx)
-	}
-
-	return types.TupleValue(tl, vl)
-}
-
-func DecodeScalar(ctx context.Context, m any) (value attr.Value, diags diag.Diagnostics) {
-	switch v := m.(type) {
-	case nil:
-		value = types.DynamicNull()
-
-	case float64:
-		value = types.NumberValue(big.NewFloat(float64(v)))
-
-	case bool:
-		value = types.BoolValue(v)
-
-	case string:
-		value = types.StringValue(v)
-
-	case []any:
-		return DecodeSequence(ctx, v)
-
-	case map[string]any:
-		return DecodeMapping(ctx, v)
-
-	case UnknownSentinel:
-		value = v.ToUnknownValue()
-
-	default:
-		diags.Append(diag.NewErrorDiagnostic("failed to decode", fmt.Sprintf("unexpected type: %T for value %#v", v, v)))
-	}
-
-	return
-}
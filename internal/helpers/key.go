@@ -0,0 +1,99 @@
+// Copyright (c) Robin Breathe and contributors
+// SPDX-License-Identifier: MPL-2.0
+
+package helpers
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/types/basetypes"
+)
+
+// Key returns a canonical encoding of v, so that values can be compared by
+// hashing rather than one pair at a time. Two values have the same key if
+// they have the same contents: a map and an object, or a list, set and
+// tuple, may share a key, as may numerically equal numbers and any two
+// nulls.
+func Key(ctx context.Context, v attr.Value) string {
+	var b strings.Builder
+	writeKey(ctx, &b, v)
+	return b.String()
+}
+
+func writeKey(ctx context.Context, b *strings.Builder, v attr.Value) {
+	if dv, ok := v.(basetypes.DynamicValue); ok && !dv.IsNull() && !dv.IsUnknown() {
+		v = dv.UnderlyingValue()
+	}
+
+	if v.IsNull() {
+		b.WriteByte('n')
+		return
+	}
+	if v.IsUnknown() {
+		b.WriteByte('u')
+		b.WriteString(strconv.Quote(v.Type(ctx).String()))
+		return
+	}
+
+	switch vv := v.(type) {
+	case basetypes.StringValue:
+		b.WriteByte('s')
+		b.WriteString(strconv.Quote(vv.ValueString()))
+
+	case basetypes.NumberValue:
+		b.WriteByte('d')
+		b.WriteString(NumberKey(vv))
+
+	case basetypes.BoolValue:
+		b.WriteString(strconv.FormatBool(vv.ValueBool()))
+
+	case basetypes.ObjectValue:
+		writeMapKey(ctx, b, vv.Attributes())
+
+	case basetypes.MapValue:
+		writeMapKey(ctx, b, vv.Elements())
+
+	case basetypes.ListValue:
+		writeListKey(ctx, b, vv.Elements())
+
+	case basetypes.SetValue:
+		writeListKey(ctx, b, vv.Elements())
+
+	case basetypes.TupleValue:
+		writeListKey(ctx, b, vv.Elements())
+
+	default:
+		b.WriteString(strconv.Quote(v.String()))
+	}
+}
+
+func writeMapKey(ctx context.Context, b *strings.Builder, m map[string]attr.Value) {
+	b.WriteByte('{')
+	for _, k := range slices.Sorted(maps.Keys(m)) {
+		b.WriteString(strconv.Quote(k))
+		b.WriteByte(':')
+		writeKey(ctx, b, m[k])
+		b.WriteByte(',')
+	}
+	b.WriteByte('}')
+}
+
+func writeListKey(ctx context.Context, b *strings.Builder, l []attr.Value) {
+	b.WriteByte('[')
+	for _, v := range l {
+		writeKey(ctx, b, v)
+		b.WriteByte(',')
+	}
+	b.WriteByte(']')
+}
+
+// NumberKey returns the exact binary form of a known number, the same for
+// numerically equal numbers whatever their precision.
+func NumberKey(n basetypes.NumberValue) string {
+	return n.ValueBigFloat().Text('p', 0)
+}
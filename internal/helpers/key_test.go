@@ -0,0 +1,91 @@
+// Copyright (c) Robin Breathe and contributors
+// SPDX-License-Identifier: MPL-2.0
+
+package helpers
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKey(t *testing.T) {
+	one := types.NumberValue(big.NewFloat(1))
+	tenth := big.NewFloat(0.1)
+
+	tests := []struct {
+		name  string
+		a, b  attr.Value
+		equal bool
+	}{
+		{
+			name:  "equal strings",
+			a:     types.StringValue("a"),
+			b:     types.DynamicValue(types.StringValue("a")),
+			equal: true,
+		},
+		{
+			name:  "string and number",
+			a:     types.StringValue("1"),
+			b:     one,
+			equal: false,
+		},
+		{
+			name:  "numbers of different precision",
+			a:     types.NumberValue(tenth),
+			b:     types.NumberValue(new(big.Float).SetPrec(512).Set(tenth)),
+			equal: true,
+		},
+		{
+			name:  "different numbers",
+			a:     one,
+			b:     types.NumberValue(big.NewFloat(2)),
+			equal: false,
+		},
+		{
+			name:  "nulls of different types",
+			a:     types.StringNull(),
+			b:     types.DynamicNull(),
+			equal: true,
+		},
+		{
+			name:  "null and empty string",
+			a:     types.StringNull(),
+			b:     types.StringValue(""),
+			equal: false,
+		},
+		{
+			name:  "unknowns of different types",
+			a:     types.StringUnknown(),
+			b:     types.NumberUnknown(),
+			equal: false,
+		},
+		{
+			name:  "map and object",
+			a:     types.MapValueMust(types.NumberType, map[string]attr.Value{"a": one, "b": one}),
+			b:     types.ObjectValueMust(map[string]attr.Type{"a": types.NumberType, "b": types.NumberType}, map[string]attr.Value{"b": one, "a": one}),
+			equal: true,
+		},
+		{
+			name:  "list and tuple",
+			a:     types.ListValueMust(types.NumberType, []attr.Value{one}),
+			b:     types.TupleValueMust([]attr.Type{types.NumberType}, []attr.Value{one}),
+			equal: true,
+		},
+		{
+			name:  "nested list and string",
+			a:     types.TupleValueMust([]attr.Type{types.StringType}, []attr.Value{types.StringValue("a,b")}),
+			b:     types.TupleValueMust([]attr.Type{types.StringType, types.StringType}, []attr.Value{types.StringValue("a"), types.StringValue("b")}),
+			equal: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.equal, Key(t.Context(), tt.a) == Key(t.Context(), tt.b))
+		})
+	}
+}
@@ -0,0 +1,45 @@
+// Copyright (c) Robin Breathe and contributors
+// SPDX-License-Identifier: MPL-2.0
+
+package helpers
+
+import (
+	"os"
+	"strings"
+
+	"github.com/hashicorp/go-hclog"
+)
+
+// logLevelVariables are the environment variables through which Terraform
+// sets the level of the provider's logs, most specific first.
+var logLevelVariables = []string{"TF_LOG_PROVIDER_DEEPMERGE", "TF_LOG_PROVIDER", "TF_LOG"}
+
+// logLevel returns the level of the provider's logs. It is read afresh each
+// time, which costs little beside a merge, so that tests can set it.
+func logLevel() hclog.Level {
+	return logLevelFrom(os.Getenv)
+}
+
+// logLevelFrom returns the level set by the first of logLevelVariables that
+// getenv finds, or Off if none is set. As in Terraform, JSON logs, and any
+// level that is not recognised, mean trace.
+func logLevelFrom(getenv func(string) string) hclog.Level {
+	for _, name := range logLevelVariables {
+		s := strings.TrimSpace(getenv(name))
+		if s == "" {
+			continue
+		}
+		if level := hclog.LevelFromString(s); level != hclog.NoLevel {
+			return level
+		}
+		return hclog.Trace
+	}
+	return hclog.Off
+}
+
+// Logging reports whether messages at level are logged, so that messages
+// which are not, and their fields, need not be built at all.
+func Logging(level hclog.Level) bool {
+	l := logLevel()
+	return l != hclog.Off && level >= l
+}
@@ -0,0 +1,56 @@
+// Copyright (c) Robin Breathe and contributors
+// SPDX-License-Identifier: MPL-2.0
+
+package helpers
+
+import (
+	"testing"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLogLevelFrom(t *testing.T) {
+	tests := []struct {
+		name     string
+		env      map[string]string
+		expected hclog.Level
+	}{
+		{
+			name:     "unset",
+			env:      map[string]string{},
+			expected: hclog.Off,
+		},
+		{
+			name:     "TF_LOG",
+			env:      map[string]string{"TF_LOG": "debug"},
+			expected: hclog.Debug,
+		},
+		{
+			name:     "provider level over TF_LOG",
+			env:      map[string]string{"TF_LOG": "trace", "TF_LOG_PROVIDER": "WARN"},
+			expected: hclog.Warn,
+		},
+		{
+			name:     "this provider's level over all providers'",
+			env:      map[string]string{"TF_LOG_PROVIDER": "off", "TF_LOG_PROVIDER_DEEPMERGE": "trace"},
+			expected: hclog.Trace,
+		},
+		{
+			name:     "JSON",
+			env:      map[string]string{"TF_LOG": "json"},
+			expected: hclog.Trace,
+		},
+		{
+			name:     "unrecognised",
+			env:      map[string]string{"TF_LOG": "verbose"},
+			expected: hclog.Trace,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, logLevelFrom(func(name string) string { return tt.env[name] }))
+		})
+	}
+}
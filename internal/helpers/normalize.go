@@ -0,0 +1,313 @@
+// Copyright (c) Robin Breathe and contributors
+// SPDX-License-Identifier: MPL-2.0
+
+package helpers
+
+import (
+	"context"
+	"maps"
+	"slices"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-framework/types/basetypes"
+)
+
+// NormalizeLists gives the elements of every tuple of objects within v a
+// single object type, filling the attributes missing from each element with
+// typed nulls, so that the tuple converts to a list of objects. A tuple whose
+// elements cannot share a type, e.g. because an attribute is a string in one
+// element and a number in another, is left as it is.
+func NormalizeLists(ctx context.Context, v attr.Value) (attr.Value, diag.Diagnostics) {
+	if v.IsNull() || v.IsUnknown() {
+		return v, nil
+	}
+
+	switch vv := v.(type) {
+	case basetypes.DynamicValue:
+		value, diags := NormalizeLists(ctx, vv.UnderlyingValue())
+		return types.DynamicValue(value), diags
+
+	case basetypes.ObjectValue:
+		attrs := vv.Attributes()
+		vm := make(map[string]attr.Value, len(attrs))
+		tm := make(map[string]attr.Type, len(attrs))
+		for k, av := range attrs {
+			value, diags := NormalizeLists(ctx, av)
+			if diags.HasError() {
+				return nil, diags
+			}
+			vm[k] = value
+			tm[k] = value.Type(ctx)
+		}
+		return types.ObjectValue(tm, vm)
+
+	case basetypes.MapValue:
+		elems := vv.Elements()
+		vm := make(map[string]attr.Value, len(elems))
+		for k, ev := range elems {
+			value, diags := NormalizeLists(ctx, ev)
+			if diags.HasError() {
+				return nil, diags
+			}
+			vm[k] = value
+		}
+		elemType, ok := commonType(ctx, slices.Collect(maps.Values(vm)))
+		if !ok {
+			elemType = vv.ElementType(ctx)
+		}
+		return types.MapValue(elemType, vm)
+
+	case basetypes.ListValue:
+		vl, diags := normalizeElements(ctx, vv.Elements())
+		if diags.HasError() {
+			return nil, diags
+		}
+		elemType, ok := commonType(ctx, vl)
+		if !ok {
+			elemType = vv.ElementType(ctx)
+		}
+		return types.ListValue(elemType, vl)
+
+	case basetypes.SetValue:
+		vl, diags := normalizeElements(ctx, vv.Elements())
+		if diags.HasError() {
+			return nil, diags
+		}
+		elemType, ok := commonType(ctx, vl)
+		if !ok {
+			elemType = vv.ElementType(ctx)
+		}
+		return types.SetValue(elemType, vl)
+
+	case basetypes.TupleValue:
+		vl, diags := normalizeElements(ctx, vv.Elements())
+		if diags.HasError() {
+			return nil, diags
+		}
+		return NormalizeSequence(ctx, vl)
+
+	default:
+		return v, nil
+	}
+}
+
+func normalizeElements(ctx context.Context, elems []attr.Value) ([]attr.Value, diag.Diagnostics) {
+	vl := make([]attr.Value, len(elems))
+	for i, ev := range elems {
+		value, diags := NormalizeLists(ctx, ev)
+		if diags.HasError() {
+			return nil, diags
+		}
+		vl[i] = value
+	}
+	return vl, nil
+}
+
+// NormalizeSequence builds a tuple from vl as NewTuple does, except
+// that if its elements are objects (or nulls) whose types can be unified,
+// every element is given the unified object type.
+func NormalizeSequence(ctx context.Context, vl []attr.Value) (attr.Value, diag.Diagnostics) {
+	tl := make([]attr.Type, len(vl))
+	for i, v := range vl {
+		tl[i] = v.Type(ctx)
+	}
+
+	unified, ok := unifyObjectTypes(tl)
+	if !ok {
+		return types.TupleValue(tl, vl)
+	}
+
+	filled := make([]attr.Value, len(vl))
+	for i, v := range vl {
+		filled[i] = fillType(ctx, v, unified)
+		tl[i] = unified
+	}
+
+	return types.TupleValue(tl, filled)
+}
+
+// unifyObjectTypes returns the single object type that every type in tl can
+// be filled out to, if there is one.
+func unifyObjectTypes(tl []attr.Type) (attr.Type, bool) {
+	var unified attr.Type = types.DynamicType
+	objects := false
+
+	for _, t := range tl {
+		switch t.(type) {
+		case basetypes.ObjectType:
+			objects = true
+		case basetypes.DynamicType:
+		default:
+			return nil, false
+		}
+
+		var ok bool
+		if unified, ok = unifyTypes(unified, t); !ok {
+			return nil, false
+		}
+	}
+
+	return unified, objects
+}
+
+// unifyTypes returns a type that values of both a and b can be filled out to:
+// the union of the attributes of two object types, or a list of the unified
+// element types of two tuples or lists. The dynamic type of an untyped null
+// unifies with any type.
+func unifyTypes(a, b attr.Type) (attr.Type, bool) {
+	if a.Equal(b) {
+		return a, true
+	}
+	if _, ok := a.(basetypes.DynamicType); ok {
+		return b, true
+	}
+	if _, ok := b.(basetypes.DynamicType); ok {
+		return a, true
+	}
+
+	switch at := a.(type) {
+	case basetypes.ObjectType:
+		bt, ok := b.(basetypes.ObjectType)
+		if !ok {
+			return nil, false
+		}
+		attrTypes := make(map[string]attr.Type, len(at.AttrTypes)+len(bt.AttrTypes))
+		for k, t := range at.AttrTypes {
+			attrTypes[k] = t
+		}
+		for k, t := range bt.AttrTypes {
+			if existing, found := attrTypes[k]; found {
+				if t, ok = unifyTypes(existing, t); !ok {
+					return nil, false
+				}
+			}
+			attrTypes[k] = t
+		}
+		return types.ObjectType{AttrTypes: attrTypes}, true
+
+	case basetypes.MapType:
+		bt, ok := b.(basetypes.MapType)
+		if !ok {
+			return nil, false
+		}
+		et, ok := unifyTypes(at.ElemType, bt.ElemType)
+		return types.MapType{ElemType: et}, ok
+
+	case basetypes.SetType:
+		bt, ok := b.(basetypes.SetType)
+		if !ok {
+			return nil, false
+		}
+		et, ok := unifyTypes(at.ElemType, bt.ElemType)
+		return types.SetType{ElemType: et}, ok
+
+	case basetypes.ListType, basetypes.TupleType:
+		switch b.(type) {
+		case basetypes.ListType, basetypes.TupleType:
+		default:
+			return nil, false
+		}
+		var et attr.Type = types.DynamicType
+		for _, t := range append(sequenceElemTypes(a), sequenceElemTypes(b)...) {
+			var ok bool
+			if et, ok = unifyTypes(et, t); !ok {
+				return nil, false
+			}
+		}
+		if _, ok := et.(basetypes.DynamicType); ok {
+			return nil, false
+		}
+		return types.ListType{ElemType: et}, true
+
+	default:
+		return nil, false
+	}
+}
+
+// sequenceElemTypes returns the element types of a list or tuple type.
+func sequenceElemTypes(t attr.Type) []attr.Type {
+	switch tt := t.(type) {
+	case basetypes.ListType:
+		return []attr.Type{tt.ElemType}
+	case basetypes.TupleType:
+		return tt.ElemTypes
+	default:
+		return nil
+	}
+}
+
+// fillType returns v as a value of type t, as returned by unifyTypes for the
+// type of v, adding typed nulls for missing attributes. A value that does not
+// match the kind of t is returned as it is.
+func fillType(ctx context.Context, v attr.Value, t attr.Type) attr.Value {
+	if dv, ok := v.(basetypes.DynamicValue); ok && !dv.IsNull() && !dv.IsUnknown() {
+		v = dv.UnderlyingValue()
+	}
+	if v.IsNull() {
+		return NullOf(t)
+	}
+	if v.IsUnknown() {
+		return UnknownOf(t)
+	}
+
+	switch tt := t.(type) {
+	case basetypes.ObjectType:
+		ov, ok := v.(basetypes.ObjectValue)
+		if !ok {
+			return v
+		}
+		attrs := ov.Attributes()
+		vm := make(map[string]attr.Value, len(tt.AttrTypes))
+		for k, at := range tt.AttrTypes {
+			if av, ok := attrs[k]; ok {
+				vm[k] = fillType(ctx, av, at)
+			} else {
+				vm[k] = NullOf(at)
+			}
+		}
+		return types.ObjectValueMust(tt.AttrTypes, vm)
+
+	case basetypes.MapType:
+		mv, ok := v.(basetypes.MapValue)
+		if !ok {
+			return v
+		}
+		elems := mv.Elements()
+		vm := make(map[string]attr.Value, len(elems))
+		for k, ev := range elems {
+			vm[k] = fillType(ctx, ev, tt.ElemType)
+		}
+		return types.MapValueMust(tt.ElemType, vm)
+
+	case basetypes.SetType:
+		sv, ok := v.(basetypes.SetValue)
+		if !ok {
+			return v
+		}
+		elems := sv.Elements()
+		vl := make([]attr.Value, len(elems))
+		for i, ev := range elems {
+			vl[i] = fillType(ctx, ev, tt.ElemType)
+		}
+		return types.SetValueMust(tt.ElemType, vl)
+
+	case basetypes.ListType:
+		var elems []attr.Value
+		switch vv := v.(type) {
+		case basetypes.ListValue:
+			elems = vv.Elements()
+		case basetypes.TupleValue:
+			elems = vv.Elements()
+		}
+		vl := make([]attr.Value, len(elems))
+		for i, ev := range elems {
+			vl[i] = fillType(ctx, ev, tt.ElemType)
+		}
+		return types.ListValueMust(tt.ElemType, vl)
+
+	default:
+		return v
+	}
+}
@@ -0,0 +1,150 @@
+// Copyright (c) Robin Breathe and contributors
+// SPDX-License-Identifier: MPL-2.0
+
+package helpers
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizeLists(t *testing.T) {
+	ruleType := types.ObjectType{AttrTypes: map[string]attr.Type{
+		"name":  types.StringType,
+		"port":  types.NumberType,
+		"cidrs": types.TupleType{ElemTypes: []attr.Type{types.StringType, types.StringType}},
+	}}
+
+	tests := []struct {
+		name     string
+		input    any
+		expected attr.Value
+	}{
+		{
+			name: "objects with different attributes",
+			input: []any{
+				map[string]any{"name": "a", "port": 80.0},
+				map[string]any{"name": "b", "cidrs": []any{"x", "y"}},
+				nil,
+			},
+			expected: types.TupleValueMust(
+				[]attr.Type{ruleType, ruleType, ruleType},
+				[]attr.Value{
+					types.ObjectValueMust(ruleType.AttrTypes, map[string]attr.Value{
+						"name":  types.StringValue("a"),
+						"port":  types.NumberValue(big.NewFloat(80)),
+						"cidrs": types.TupleNull([]attr.Type{types.StringType, types.StringType}),
+					}),
+					types.ObjectValueMust(ruleType.AttrTypes, map[string]attr.Value{
+						"name":  types.StringValue("b"),
+						"port":  types.NumberNull(),
+						"cidrs": types.TupleValueMust([]attr.Type{types.StringType, types.StringType}, []attr.Value{types.StringValue("x"), types.StringValue("y")}),
+					}),
+					types.ObjectNull(ruleType.AttrTypes),
+				},
+			),
+		},
+		{
+			name: "lists of different lengths",
+			input: []any{
+				map[string]any{"cidrs": []any{"x"}},
+				map[string]any{"cidrs": []any{"x", "y"}},
+			},
+			expected: types.TupleValueMust(
+				[]attr.Type{
+					types.ObjectType{AttrTypes: map[string]attr.Type{"cidrs": types.ListType{ElemType: types.StringType}}},
+					types.ObjectType{AttrTypes: map[string]attr.Type{"cidrs": types.ListType{ElemType: types.StringType}}},
+				},
+				[]attr.Value{
+					types.ObjectValueMust(map[string]attr.Type{"cidrs": types.ListType{ElemType: types.StringType}}, map[string]attr.Value{
+						"cidrs": types.ListValueMust(types.StringType, []attr.Value{types.StringValue("x")}),
+					}),
+					types.ObjectValueMust(map[string]attr.Type{"cidrs": types.ListType{ElemType: types.StringType}}, map[string]attr.Value{
+						"cidrs": types.ListValueMust(types.StringType, []attr.Value{types.StringValue("x"), types.StringValue("y")}),
+					}),
+				},
+			),
+		},
+		{
+			name: "conflicting attribute types",
+			input: []any{
+				map[string]any{"port": 80.0},
+				map[string]any{"port": "http"},
+			},
+			expected: types.TupleValueMust(
+				[]attr.Type{
+					types.ObjectType{AttrTypes: map[string]attr.Type{"port": types.NumberType}},
+					types.ObjectType{AttrTypes: map[string]attr.Type{"port": types.StringType}},
+				},
+				[]attr.Value{
+					types.ObjectValueMust(map[string]attr.Type{"port": types.NumberType}, map[string]attr.Value{"port": types.NumberValue(big.NewFloat(80))}),
+					types.ObjectValueMust(map[string]attr.Type{"port": types.StringType}, map[string]attr.Value{"port": types.StringValue("http")}),
+				},
+			),
+		},
+		{
+			name:  "scalars",
+			input: []any{"a", 1.0},
+			expected: types.TupleValueMust(
+				[]attr.Type{types.StringType, types.NumberType},
+				[]attr.Value{types.StringValue("a"), types.NumberValue(big.NewFloat(1))},
+			),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			normalized, diags := NormalizeLists(t.Context(), literal(t, tt.input))
+			assert.False(t, diags.HasError())
+			assert.True(t, normalized.Equal(tt.expected), "got %s", normalized)
+		})
+	}
+}
+
+// literal builds a value from a Go literal as Terraform would from the same
+// expression in HCL: maps become objects, and slices tuples.
+func literal(t *testing.T, x any) attr.Value {
+	switch v := x.(type) {
+	case nil:
+		return types.DynamicNull()
+	case string:
+		return types.StringValue(v)
+	case float64:
+		return types.NumberValue(big.NewFloat(v))
+	case map[string]any:
+		vm := make(map[string]attr.Value, len(v))
+		for k, elem := range v {
+			vm[k] = literal(t, elem)
+		}
+		value, diags := NewObject(t.Context(), vm)
+		assert.False(t, diags.HasError())
+		return value
+	case []any:
+		vl := make([]attr.Value, len(v))
+		for i, elem := range v {
+			vl[i] = literal(t, elem)
+		}
+		value, diags := NewTuple(t.Context(), vl)
+		assert.False(t, diags.HasError())
+		return value
+	default:
+		t.Fatalf("unsupported literal %#v", x)
+		return nil
+	}
+}
+
+func TestFillTypeMismatch(t *testing.T) {
+	v := types.StringValue("a")
+
+	for _, typ := range []attr.Type{
+		types.ObjectType{AttrTypes: map[string]attr.Type{"a": types.StringType}},
+		types.MapType{ElemType: types.StringType},
+		types.SetType{ElemType: types.StringType},
+	} {
+		assert.True(t, fillType(t.Context(), v, typ).Equal(v), "for %s", typ)
+	}
+}
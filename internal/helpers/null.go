@@ -0,0 +1,34 @@
+// Copyright (c) Robin Breathe and contributors
+// SPDX-License-Identifier: MPL-2.0
+
+package helpers
+
+import (
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-framework/types/basetypes"
+)
+
+// NullOf returns the null value of type t.
+func NullOf(t attr.Type) attr.Value {
+	switch t := t.(type) {
+	case basetypes.StringType:
+		return types.StringNull()
+	case basetypes.NumberType:
+		return types.NumberNull()
+	case basetypes.BoolType:
+		return types.BoolNull()
+	case basetypes.MapType:
+		return types.MapNull(t.ElemType)
+	case basetypes.ObjectType:
+		return types.ObjectNull(t.AttrTypes)
+	case basetypes.ListType:
+		return types.ListNull(t.ElemType)
+	case basetypes.SetType:
+		return types.SetNull(t.ElemType)
+	case basetypes.TupleType:
+		return types.TupleNull(t.ElemTypes)
+	default:
+		return types.DynamicNull()
+	}
+}
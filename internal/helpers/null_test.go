@@ -0,0 +1,67 @@
+// Copyright (c) Robin Breathe and contributors
+// SPDX-License-Identifier: MPL-2.0
+
+package helpers
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNullOf(t *testing.T) {
+	tests := []struct {
+		name     string
+		typ      attr.Type
+		expected attr.Value
+	}{
+		{
+			name:     "string type",
+			typ:      types.StringType,
+			expected: types.StringNull(),
+		},
+		{
+			name:     "number type",
+			typ:      types.NumberType,
+			expected: types.NumberNull(),
+		},
+		{
+			name:     "bool type",
+			typ:      types.BoolType,
+			expected: types.BoolNull(),
+		},
+		{
+			name:     "map type",
+			typ:      types.MapType{ElemType: types.StringType},
+			expected: types.MapNull(types.StringType),
+		},
+		{
+			name:     "object type",
+			typ:      types.ObjectType{AttrTypes: map[string]attr.Type{"key": types.StringType}},
+			expected: types.ObjectNull(map[string]attr.Type{"key": types.StringType}),
+		},
+		{
+			name:     "list type",
+			typ:      types.ListType{ElemType: types.NumberType},
+			expected: types.ListNull(types.NumberType),
+		},
+		{
+			name:     "set type",
+			typ:      types.SetType{ElemType: types.BoolType},
+			expected: types.SetNull(types.BoolType),
+		},
+		{
+			name:     "tuple type",
+			typ:      types.TupleType{ElemTypes: []attr.Type{types.StringType}},
+			expected: types.TupleNull([]attr.Type{types.StringType}),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, NullOf(tt.typ))
+		})
+	}
+}
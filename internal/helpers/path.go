@@ -0,0 +1,43 @@
+// Copyright (c) Robin Breathe and contributors
+// SPDX-License-Identifier: MPL-2.0
+
+package helpers
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+)
+
+// FormatPath renders a path within a value in Terraform's own syntax, such
+// as services["api"].ports[2], for error messages. An attribute whose name
+// is not an identifier is written as a key, and a set element, which has no
+// index of its own, as [*].
+func FormatPath(p path.Path) string {
+	var b strings.Builder
+	for _, step := range p.Steps() {
+		switch s := step.(type) {
+		case path.PathStepAttributeName:
+			if !hclsyntax.ValidIdentifier(string(s)) {
+				fmt.Fprintf(&b, "[%q]", string(s))
+				continue
+			}
+			if b.Len() > 0 {
+				b.WriteByte('.')
+			}
+			b.WriteString(string(s))
+
+		case path.PathStepElementKeyString:
+			fmt.Fprintf(&b, "[%q]", string(s))
+
+		case path.PathStepElementKeyInt:
+			fmt.Fprintf(&b, "[%d]", int64(s))
+
+		default:
+			b.WriteString("[*]")
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,57 @@
+// Copyright (c) Robin Breathe and contributors
+// SPDX-License-Identifier: MPL-2.0
+
+package helpers
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormatPath(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    path.Path
+		expected string
+	}{
+		{
+			name:     "empty",
+			input:    path.Empty(),
+			expected: "",
+		},
+		{
+			name:     "attributes",
+			input:    path.Root("vpc").AtName("cidr"),
+			expected: "vpc.cidr",
+		},
+		{
+			name:     "map key and list index",
+			input:    path.Root("services").AtMapKey("api").AtName("ports").AtListIndex(2),
+			expected: `services["api"].ports[2]`,
+		},
+		{
+			name:     "map key first",
+			input:    path.Empty().AtMapKey("web").AtName("port"),
+			expected: `["web"].port`,
+		},
+		{
+			name:     "attribute that is not an identifier",
+			input:    path.Root("tags").AtName("my tag").AtName("value"),
+			expected: `tags["my tag"].value`,
+		},
+		{
+			name:     "set element",
+			input:    path.Root("cidrs").AtSetValue(types.StringValue("10.0.0.0/8")),
+			expected: "cidrs[*]",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, FormatPath(tt.input))
+		})
+	}
+}
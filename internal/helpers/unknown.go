@@ -4,37 +4,14 @@
 package helpers
 
 import (
-	"context"
-
 	"github.com/hashicorp/terraform-plugin-framework/attr"
 	"github.com/hashicorp/terraform-plugin-framework/types"
 	"github.com/hashicorp/terraform-plugin-framework/types/basetypes"
 )
 
-// UnknownSentinel represents an unknown Terraform value during encoding.
-// It carries type information needed to reconstruct the appropriate unknown
-// value during decoding.
-type UnknownSentinel struct {
-	Type attr.Type
-}
-
-// IsUnknownSentinel checks if a value is our sentinel type.
-func IsUnknownSentinel(v any) bool {
-	_, ok := v.(UnknownSentinel)
-	return ok
-}
-
-// CreateUnknownSentinel creates a sentinel from a Terraform unknown value.
-// The sentinel preserves type information so the unknown can be reconstructed
-// with the correct Terraform type during decoding.
-func CreateUnknownSentinel(ctx context.Context, v attr.Value) UnknownSentinel {
-	return UnknownSentinel{Type: v.Type(ctx)}
-}
-
-// ToUnknownValue converts a sentinel back to a Terraform unknown value
-// with the appropriate type.
-func (s UnknownSentinel) ToUnknownValue() attr.Value {
-	switch t := s.Type.(type) {
+// UnknownOf returns an unknown value of type t.
+func UnknownOf(t attr.Type) attr.Value {
+	switch t := t.(type) {
 	case basetypes.StringType:
 		return types.StringUnknown()
 	case basetypes.NumberType:
@@ -11,110 +11,15 @@ import (
 	"github.com/stretchr/testify/assert"
 )
 
-func TestIsUnknownSentinel(t *testing.T) {
+func TestUnknownOf(t *testing.T) {
 	tests := []struct {
 		name     string
-		input    any
-		expected bool
-	}{
-		{
-			name:     "sentinel struct",
-			input:    UnknownSentinel{Type: types.StringType},
-			expected: true,
-		},
-		{
-			name:     "string value",
-			input:    "foo",
-			expected: false,
-		},
-		{
-			name:     "nil value",
-			input:    nil,
-			expected: false,
-		},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			result := IsUnknownSentinel(tt.input)
-			assert.Equal(t, tt.expected, result)
-		})
-	}
-}
-
-func TestCreateUnknownSentinel(t *testing.T) {
-	tests := []struct {
-		name         string
-		input        attr.Value
-		expectedType attr.Type
-	}{
-		{
-			name:         "string unknown",
-			input:        types.StringUnknown(),
-			expectedType: types.StringType,
-		},
-		{
-			name:         "number unknown",
-			input:        types.NumberUnknown(),
-			expectedType: types.NumberType,
-		},
-		{
-			name:         "bool unknown",
-			input:        types.BoolUnknown(),
-			expectedType: types.BoolType,
-		},
-		{
-			name:         "map unknown",
-			input:        types.MapUnknown(types.StringType),
-			expectedType: types.MapType{ElemType: types.StringType},
-		},
-		{
-			name:         "list unknown with string element",
-			input:        types.ListUnknown(types.StringType),
-			expectedType: types.ListType{ElemType: types.StringType},
-		},
-		{
-			name:         "set unknown with bool element",
-			input:        types.SetUnknown(types.BoolType),
-			expectedType: types.SetType{ElemType: types.BoolType},
-		},
-		{
-			name:  "object unknown",
-			input: types.ObjectUnknown(map[string]attr.Type{"foo": types.StringType, "bar": types.NumberType}),
-			expectedType: types.ObjectType{AttrTypes: map[string]attr.Type{
-				"foo": types.StringType,
-				"bar": types.NumberType,
-			}},
-		},
-		{
-			name:         "tuple unknown",
-			input:        types.TupleUnknown([]attr.Type{types.StringType, types.NumberType, types.BoolType}),
-			expectedType: types.TupleType{ElemTypes: []attr.Type{types.StringType, types.NumberType, types.BoolType}},
-		},
-		{
-			name:         "dynamic unknown",
-			input:        types.DynamicUnknown(),
-			expectedType: types.DynamicType,
-		},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			sentinel := CreateUnknownSentinel(t.Context(), tt.input)
-			assert.Equal(t, tt.expectedType, sentinel.Type)
-		})
-	}
-}
-
-func TestUnknownSentinel_ToUnknownValue(t *testing.T) {
-	tests := []struct {
-		name     string
-		sentinel UnknownSentinel
+		typ      attr.Type
 		validate func(t *testing.T, result attr.Value)
 	}{
 		{
-			name:     "string type",
-			sentinel: UnknownSentinel{Type: types.StringType},
+			name: "string type",
+			typ:  types.StringType,
 			validate: func(t *testing.T, result attr.Value) {
 				assert.True(t, result.IsUnknown())
 				_, ok := result.(types.String)
@@ -122,8 +27,8 @@ func TestUnknownSentinel_ToUnknownValue(t *testing.T) {
 			},
 		},
 		{
-			name:     "number type",
-			sentinel: UnknownSentinel{Type: types.NumberType},
+			name: "number type",
+			typ:  types.NumberType,
 			validate: func(t *testing.T, result attr.Value) {
 				assert.True(t, result.IsUnknown())
 				_, ok := result.(types.Number)
@@ -131,8 +36,8 @@ func TestUnknownSentinel_ToUnknownValue(t *testing.T) {
 			},
 		},
 		{
-			name:     "bool type",
-			sentinel: UnknownSentinel{Type: types.BoolType},
+			name: "bool type",
+			typ:  types.BoolType,
 			validate: func(t *testing.T, result attr.Value) {
 				assert.True(t, result.IsUnknown())
 				_, ok := result.(types.Bool)
@@ -140,8 +45,8 @@ func TestUnknownSentinel_ToUnknownValue(t *testing.T) {
 			},
 		},
 		{
-			name:     "map type with element type",
-			sentinel: UnknownSentinel{Type: types.MapType{ElemType: types.StringType}},
+			name: "map type with element type",
+			typ:  types.MapType{ElemType: types.StringType},
 			validate: func(t *testing.T, result attr.Value) {
 				assert.True(t, result.IsUnknown())
 				m, ok := result.(types.Map)
@@ -150,8 +55,8 @@ func TestUnknownSentinel_ToUnknownValue(t *testing.T) {
 			},
 		},
 		{
-			name:     "list type with element type",
-			sentinel: UnknownSentinel{Type: types.ListType{ElemType: types.NumberType}},
+			name: "list type with element type",
+			typ:  types.ListType{ElemType: types.NumberType},
 			validate: func(t *testing.T, result attr.Value) {
 				assert.True(t, result.IsUnknown())
 				l, ok := result.(types.List)
@@ -160,8 +65,8 @@ func TestUnknownSentinel_ToUnknownValue(t *testing.T) {
 			},
 		},
 		{
-			name:     "set type with element type",
-			sentinel: UnknownSentinel{Type: types.SetType{ElemType: types.BoolType}},
+			name: "set type with element type",
+			typ:  types.SetType{ElemType: types.BoolType},
 			validate: func(t *testing.T, result attr.Value) {
 				assert.True(t, result.IsUnknown())
 				s, ok := result.(types.Set)
@@ -171,9 +76,7 @@ func TestUnknownSentinel_ToUnknownValue(t *testing.T) {
 		},
 		{
 			name: "object type with attribute types",
-			sentinel: UnknownSentinel{
-				Type: types.ObjectType{AttrTypes: map[string]attr.Type{"foo": types.StringType}},
-			},
+			typ:  types.ObjectType{AttrTypes: map[string]attr.Type{"foo": types.StringType}},
 			validate: func(t *testing.T, result attr.Value) {
 				assert.True(t, result.IsUnknown())
 				o, ok := result.(types.Object)
@@ -184,9 +87,7 @@ func TestUnknownSentinel_ToUnknownValue(t *testing.T) {
 		},
 		{
 			name: "tuple type with element types",
-			sentinel: UnknownSentinel{
-				Type: types.TupleType{ElemTypes: []attr.Type{types.StringType, types.NumberType}},
-			},
+			typ:  types.TupleType{ElemTypes: []attr.Type{types.StringType, types.NumberType}},
 			validate: func(t *testing.T, result attr.Value) {
 				assert.True(t, result.IsUnknown())
 				tu, ok := result.(types.Tuple)
@@ -196,8 +97,8 @@ func TestUnknownSentinel_ToUnknownValue(t *testing.T) {
 			},
 		},
 		{
-			name:     "dynamic type",
-			sentinel: UnknownSentinel{Type: types.DynamicType},
+			name: "dynamic type",
+			typ:  types.DynamicType,
 			validate: func(t *testing.T, result attr.Value) {
 				assert.True(t, result.IsUnknown())
 				_, ok := result.(types.Dynamic)
@@ -205,8 +106,8 @@ func TestUnknownSentinel_ToUnknownValue(t *testing.T) {
 			},
 		},
 		{
-			name:     "nil type falls back to dynamic",
-			sentinel: UnknownSentinel{Type: nil},
+			name: "nil type falls back to dynamic",
+			typ:  nil,
 			validate: func(t *testing.T, result attr.Value) {
 				assert.True(t, result.IsUnknown())
 				_, ok := result.(types.Dynamic)
@@ -217,7 +118,7 @@ func TestUnknownSentinel_ToUnknownValue(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result := tt.sentinel.ToUnknownValue()
+			result := UnknownOf(tt.typ)
 			tt.validate(t, result)
 		})
 	}
@@ -0,0 +1,167 @@
+// Copyright (c) Robin Breathe and contributors
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/types/basetypes"
+
+	"github.com/isometry/terraform-provider-deepmerge/internal/helpers"
+)
+
+// Kubernetes-style directive keys that may appear in overlay maps to control
+// how that particular node is merged. They are never part of the result.
+const (
+	patchDirective      = "$patch"
+	retainKeysDirective = "$retainKeys"
+)
+
+// isDirectiveKey reports whether key is one of the directive keys, which are
+// reserved wherever they appear in an argument.
+func isDirectiveKey(key string) bool {
+	return key == patchDirective || key == retainKeysDirective
+}
+
+// findDirectives walks v, found at p, validating any directives it contains.
+// It reports whether a directive was found, and whether all directive values
+// are known.
+func findDirectives(p path.Path, v attr.Value) (found bool, known bool, err error) {
+	known = true
+
+	if v == nil || v.IsNull() || v.IsUnknown() {
+		return false, true, nil
+	}
+
+	var children map[string]attr.Value
+	at := path.Path.AtName
+
+	switch vv := v.(type) {
+	case basetypes.DynamicValue:
+		return findDirectives(p, vv.UnderlyingValue())
+
+	case basetypes.ObjectValue:
+		children = vv.Attributes()
+
+	case basetypes.MapValue:
+		children = vv.Elements()
+		at = path.Path.AtMapKey
+
+	case basetypes.ListValue, basetypes.SetValue, basetypes.TupleValue:
+		elems, _ := sequenceElements(vv)
+		for i, elem := range elems {
+			f, k, err := findDirectives(p.AtListIndex(i), elem)
+			if err != nil {
+				return false, false, err
+			}
+			found, known = found || f, known && k
+		}
+		return found, known, nil
+
+	default:
+		return false, true, nil
+	}
+
+	for key, child := range children {
+		if isDirectiveKey(key) {
+			found = true
+			k, err := validateDirective(p, key, child)
+			if err != nil {
+				return false, false, err
+			}
+			known = known && k
+			continue
+		}
+
+		f, k, err := findDirectives(at(p, key), child)
+		if err != nil {
+			return false, false, err
+		}
+		found, known = found || f, known && k
+	}
+
+	return found, known, nil
+}
+
+// validateDirective checks the value of a single directive key in the map
+// found at p.
+func validateDirective(p path.Path, key string, v attr.Value) (known bool, err error) {
+	if dv, ok := v.(basetypes.DynamicValue); ok && !dv.IsUnknown() && !dv.IsNull() {
+		v = dv.UnderlyingValue()
+	}
+
+	if v.IsUnknown() {
+		return false, nil
+	}
+
+	switch key {
+	case patchDirective:
+		if s, ok := v.(basetypes.StringValue); ok {
+			switch s.ValueString() {
+			case "replace", "delete", "merge":
+				return true, nil
+			}
+		}
+		return false, fmt.Errorf("%s must be one of \"replace\", \"delete\" or \"merge\"", directiveName(p, key))
+
+	default:
+		elems, ok := sequenceElements(v)
+		if !ok {
+			return false, fmt.Errorf("%s must be a list of keys", directiveName(p, key))
+		}
+		for _, elem := range elems {
+			if elem.IsUnknown() {
+				return false, nil
+			}
+			if _, ok := elem.(basetypes.StringValue); !ok || elem.IsNull() {
+				return false, fmt.Errorf("%s must be a list of keys", directiveName(p, key))
+			}
+		}
+		return true, nil
+	}
+}
+
+// directiveName names a directive key in an error message, along with the
+// path of the map holding it.
+func directiveName(p path.Path, key string) string {
+	if len(p.Steps()) == 0 {
+		return key
+	}
+	return fmt.Sprintf("%s at %s", key, helpers.FormatPath(p))
+}
+
+// patchOf returns the $patch directive of a map, if any.
+func patchOf(v attr.Value) string {
+	if patch, ok := unwrap(attributes(v)[patchDirective]).(basetypes.StringValue); ok && !patch.IsNull() && !patch.IsUnknown() {
+		return patch.ValueString()
+	}
+	return ""
+}
+
+// retainKeysOf returns the set of keys listed by the $retainKeys directive of
+// a map, if any.
+func retainKeysOf(v attr.Value) (map[string]bool, bool) {
+	keys, ok := sequenceElements(unwrap(attributes(v)[retainKeysDirective]))
+	if !ok {
+		return nil, false
+	}
+
+	retain := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		if s, ok := unwrap(key).(basetypes.StringValue); ok && !s.IsNull() && !s.IsUnknown() {
+			retain[s.ValueString()] = true
+		}
+	}
+
+	return retain, true
+}
+
+// isReplaceMarker reports whether a list element is the {"$patch" = "replace"}
+// marker requesting that the list replace, rather than merge with, the
+// earlier list.
+func isReplaceMarker(elem attr.Value) bool {
+	return len(attributes(elem)) == 1 && patchOf(elem) == "replace"
+}
@@ -0,0 +1,1087 @@
+// Copyright (c) Robin Breathe and contributors
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-framework/types/basetypes"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+
+	"github.com/isometry/terraform-provider-deepmerge/internal/helpers"
+)
+
+// kind classifies a value by how it merges.
+type kind int
+
+const (
+	kindNull kind = iota
+	kindUnknown
+	kindScalar // a string, number or bool
+	kindObject
+	kindMap
+	kindTuple
+	kindList
+	kindSet
+)
+
+func (k kind) isMap() bool        { return k == kindObject || k == kindMap }
+func (k kind) isList() bool       { return k == kindTuple || k == kindList || k == kindSet }
+func (k kind) isStructured() bool { return k.isMap() || k.isList() }
+
+// kindOf classifies a value, which may be nil.
+func kindOf(v attr.Value) kind {
+	v = unwrap(v)
+	switch {
+	case v == nil || v.IsNull():
+		return kindNull
+	case v.IsUnknown():
+		return kindUnknown
+	}
+
+	switch v.(type) {
+	case basetypes.ObjectValue:
+		return kindObject
+	case basetypes.MapValue:
+		return kindMap
+	case basetypes.TupleValue:
+		return kindTuple
+	case basetypes.ListValue:
+		return kindList
+	case basetypes.SetValue:
+		return kindSet
+	default:
+		return kindScalar
+	}
+}
+
+// unwrap returns the value held by a known, non-null dynamic value.
+func unwrap(v attr.Value) attr.Value {
+	if dv, ok := v.(basetypes.DynamicValue); ok && !dv.IsNull() && !dv.IsUnknown() {
+		return dv.UnderlyingValue()
+	}
+	return v
+}
+
+// attributes returns the attributes of an object, or the elements of a map.
+func attributes(v attr.Value) map[string]attr.Value {
+	switch vv := unwrap(v).(type) {
+	case basetypes.ObjectValue:
+		return vv.Attributes()
+	case basetypes.MapValue:
+		return vv.Elements()
+	default:
+		return nil
+	}
+}
+
+// sequenceElements returns the elements of a list, set or tuple value.
+func sequenceElements(v attr.Value) ([]attr.Value, bool) {
+	switch vv := v.(type) {
+	case basetypes.ListValue:
+		return vv.Elements(), true
+	case basetypes.SetValue:
+		return vv.Elements(), true
+	case basetypes.TupleValue:
+		return vv.Elements(), true
+	default:
+		return nil, false
+	}
+}
+
+// elemTypeOf returns the element type of a map, list or set.
+func elemTypeOf(ctx context.Context, v attr.Value) attr.Type {
+	switch vv := unwrap(v).(type) {
+	case basetypes.MapValue:
+		return vv.ElementType(ctx)
+	case basetypes.ListValue:
+		return vv.ElementType(ctx)
+	case basetypes.SetValue:
+		return vv.ElementType(ctx)
+	default:
+		return nil
+	}
+}
+
+// node is a merged value under construction. A map or list that a later
+// layer merges into is opened into a node for each of its keys or elements,
+// which are then updated in place; any other value is held as the layer
+// supplied it, so that subtrees no later layer touches are reused as they
+// are rather than rebuilt.
+type node struct {
+	value attr.Value // the value, unless the node is open
+
+	open     bool
+	shape    kind      // the kind of an open node
+	elemType attr.Type // the element type of an open map, list or set, if every layer agrees on it
+	keys     map[string]*node
+	elems    []*node
+}
+
+func leaf(v attr.Value) *node {
+	return &node{value: unwrap(v)}
+}
+
+// kind classifies the value of n, which may be nil for a missing value.
+func (n *node) kind() kind {
+	switch {
+	case n == nil:
+		return kindNull
+	case n.open:
+		return n.shape
+	default:
+		return kindOf(n.value)
+	}
+}
+
+// openMap opens a map or object node so that it can be merged into.
+func (n *node) openMap(ctx context.Context) {
+	if n.open {
+		return
+	}
+
+	attrs := attributes(n.value)
+	n.keys = make(map[string]*node, len(attrs))
+	for key, v := range attrs {
+		n.keys[key] = leaf(v)
+	}
+	n.open, n.shape, n.elemType, n.value = true, kindOf(n.value), elemTypeOf(ctx, n.value), nil
+}
+
+// openList opens a list, set or tuple node so that it can be merged into.
+func (n *node) openList(ctx context.Context) {
+	if n.open {
+		return
+	}
+
+	elems, _ := sequenceElements(n.value)
+	n.elems = make([]*node, len(elems))
+	for i, v := range elems {
+		n.elems[i] = leaf(v)
+	}
+	n.open, n.shape, n.elemType, n.value = true, kindOf(n.value), elemTypeOf(ctx, n.value), nil
+}
+
+// generalize records that src was merged into n: a merged map, list or set
+// keeps its type only if every layer agrees on it, and otherwise becomes an
+// object or tuple.
+func (n *node) generalize(ctx context.Context, src attr.Value) {
+	if kindOf(src) != n.shape {
+		if n.shape.isMap() {
+			n.shape = kindObject
+		} else {
+			n.shape = kindTuple
+		}
+		n.elemType = nil
+		return
+	}
+
+	if n.elemType != nil && !n.elemType.Equal(elemTypeOf(ctx, src)) {
+		n.elemType = nil
+	}
+}
+
+// finish returns the value of n, building any open maps and lists within it.
+func (n *node) finish(ctx context.Context) (attr.Value, diag.Diagnostics) {
+	if !n.open {
+		return n.value, nil
+	}
+
+	var value attr.Value
+	var diags diag.Diagnostics
+
+	if n.shape.isMap() {
+		vm := make(map[string]attr.Value, len(n.keys))
+		for key, child := range n.keys {
+			if vm[key], diags = child.finish(ctx); diags.HasError() {
+				return nil, diags
+			}
+		}
+
+		if n.shape == kindMap {
+			value, diags = helpers.NewMapOrObject(ctx, vm, n.elemType)
+		} else {
+			value, diags = helpers.NewObject(ctx, vm)
+		}
+	} else {
+		vl := make([]attr.Value, len(n.elems))
+		for i, child := range n.elems {
+			if vl[i], diags = child.finish(ctx); diags.HasError() {
+				return nil, diags
+			}
+		}
+
+		switch n.shape {
+		case kindList:
+			value, diags = helpers.NewListOrTuple(ctx, vl, n.elemType)
+		case kindSet:
+			value, diags = helpers.NewSetOrTuple(ctx, vl, n.elemType)
+		default:
+			value, diags = helpers.NewTuple(ctx, vl)
+		}
+	}
+
+	if diags.HasError() {
+		return nil, diags
+	}
+
+	// the node is closed again, in case a later layer merges into it
+	*n = node{value: value}
+	return value, nil
+}
+
+// kindName describes the kind of a value in Terraform terms.
+func (n *node) kindName() string {
+	switch k := n.kind(); {
+	case k.isMap():
+		return "map"
+	case k.isList():
+		return "list"
+	default:
+		return typeName(n.value)
+	}
+}
+
+// merger merges the layers of a mergo call, one at a time, into a tree of
+// nodes.
+type merger struct {
+	mergeOptions
+	with_directives bool
+	path_rules      pathRules
+
+	// the layer being merged, the result it is merged into, and where to
+	// record the origin of each merged value (if needed)
+	layer   int64
+	root    *node
+	origins *origins
+
+	// whether to log each decision at trace level, and the argument that
+	// supplied the layer, to name in those logs
+	tracing  bool
+	argument helpers.Argument
+}
+
+// mergeMaps merges the map src, found at path, into the open map node dst.
+func (m *merger) mergeMaps(ctx context.Context, path []string, dst *node, src attr.Value) error {
+	if m.patch(src) == "replace" {
+		clear(dst.keys)
+		m.origins.remove(path)
+	}
+
+	attrs := attributes(src)
+	for _, key := range slices.Sorted(maps.Keys(attrs)) {
+		if m.with_directives && isDirectiveKey(key) {
+			continue
+		}
+
+		if target, ok := m.knockedOut(key); ok {
+			// knockout: remove the key from the merged map
+			if m.tracing {
+				m.trace(ctx, "Key knocked out", append(slices.Clip(path), target), nil)
+			}
+			delete(dst.keys, target)
+			m.origins.remove(append(slices.Clip(path), target))
+			continue
+		}
+
+		srcElem := unwrap(attrs[key])
+		if m.patch(srcElem) == "delete" {
+			if m.tracing {
+				m.trace(ctx, "Key deleted by $patch", append(slices.Clip(path), key), nil)
+			}
+			delete(dst.keys, key)
+			m.origins.remove(append(slices.Clip(path), key))
+			continue
+		}
+
+		merged, ok, err := m.mergeValues(ctx, append(slices.Clip(path), key), dst.keys[key], srcElem)
+		if err != nil {
+			return err
+		}
+		if ok {
+			dst.keys[key] = merged
+		}
+	}
+
+	if retain, ok := m.retainKeys(src); ok {
+		for key := range dst.keys {
+			if !retain[key] {
+				if m.tracing {
+					m.trace(ctx, "Key dropped by $retainKeys", append(slices.Clip(path), key), nil)
+				}
+				delete(dst.keys, key)
+				m.origins.remove(append(slices.Clip(path), key))
+			}
+		}
+	}
+
+	dst.generalize(ctx, src)
+	return nil
+}
+
+// mergeValues merges the src value found at path onto the dst node at the
+// same path (nil if there is none), whether in a map or, for zip_lists and
+// merge_lists_by, a list. It returns the merged node, and false if dst should
+// be left as is.
+func (m *merger) mergeValues(ctx context.Context, path []string, dst *node, src attr.Value) (*node, bool, error) {
+	src = unwrap(src)
+	srcKind, dstKind := kindOf(src), dst.kind()
+	rule, override, deep := m.modeAt(path)
+	override = override || m.fillsZero(rule, dst)
+
+	if srcKind == kindUnknown || dstKind == kindUnknown {
+		return m.mergeUnknown(ctx, path, dst, src)
+	}
+
+	dstNull := dstKind == kindNull
+
+	strategy := ""
+	if deep && srcKind.isList() && dstKind.isList() {
+		strategy = m.listStrategy(rule, dst, src)
+	}
+
+	switch {
+	case srcKind == kindNull:
+		// no_null_override: keep the existing value
+		ok := (m.with_null_override && override) || dstNull
+		if !ok && m.tracing {
+			m.trace(ctx, "Null ignored", path, map[string]any{"kept": dst.kindName()})
+		}
+		return m.replaced(ctx, path, src, ok)
+
+	case !m.with_empty_override && isEmpty(src) && !dstNull:
+		// no_empty_override: keep the existing value
+		if m.tracing {
+			m.trace(ctx, "Empty value ignored", path, map[string]any{"kept": dst.kindName()})
+		}
+		return nil, false, nil
+
+	case rule == "no_override" && !dstNull:
+		if m.tracing {
+			m.trace(ctx, "Earlier value kept", path, map[string]any{"reason": "no_override path rule"})
+		}
+		return nil, false, nil
+
+	case rule == "replace":
+		return m.replaced(ctx, path, src, true)
+
+	case !dstNull && dstKind.isStructured() != srcKind.isStructured() || dstKind.isStructured() && dstKind.isMap() != srcKind.isMap():
+		// type conflict: a map or list is replaced by a different kind of value
+		switch m.type_conflicts {
+		case "strict":
+			return nil, false, fmt.Errorf("type conflict at %s: cannot merge %s into %s", m.describePath(path), leaf(src).kindName(), dst.kindName())
+		case "keep":
+			if !srcKind.isStructured() {
+				if m.tracing {
+					m.trace(ctx, "Earlier value kept", path, map[string]any{"reason": "type conflict", "kept": dst.kindName(), "ignored": leaf(src).kindName()})
+				}
+				return nil, false, m.conflict(ctx, path, dst, src)
+			}
+		}
+		if err := m.conflict(ctx, path, dst, src); err != nil {
+			return nil, false, err
+		}
+		return m.replaced(ctx, path, src, override || dstNull)
+
+	case deep && srcKind.isMap() && dstKind.isMap():
+		// recursive call
+		dst.openMap(ctx)
+		return dst, true, m.mergeMaps(ctx, path, dst, src)
+
+	case strategy != "":
+		merged, err := m.mergeLists(ctx, path, strategy, dst, src)
+		return merged, true, err
+
+	case !override && !dstNull:
+		if m.tracing {
+			m.trace(ctx, "Earlier value kept", path, map[string]any{"reason": "no_override"})
+		}
+		return nil, false, m.conflict(ctx, path, dst, src)
+
+	default:
+		if err := m.conflict(ctx, path, dst, src); err != nil {
+			return nil, false, err
+		}
+		return m.replaced(ctx, path, src, true)
+	}
+}
+
+// modeAt returns the path rule matching path, if any, whether a later value
+// overrides an earlier one there, and whether maps and lists there are
+// merged rather than replaced.
+func (m *merger) modeAt(path []string) (rule string, override bool, deep bool) {
+	rule = m.path_rules.match(path)
+
+	override = m.with_override
+	switch rule {
+	case "replace":
+		override = true
+	case "no_override":
+		override = false
+	}
+
+	// beyond the depth limit, values are replaced rather than merged
+	deep = m.depth == 0 || int64(len(path)) < m.depth
+
+	return rule, override, deep
+}
+
+// fillsZero reports whether dst, though no_override is set, is replaced
+// because it is a zero value: an empty string, list or map, false or 0. A
+// no_override path rule keeps even zero values.
+func (m *merger) fillsZero(rule string, dst *node) bool {
+	return !m.with_override && rule != "no_override" && dst.isZero()
+}
+
+// isZero reports whether n is an empty string, list or map, false or 0.
+func (n *node) isZero() bool {
+	switch k := n.kind(); {
+	case k == kindNull || k == kindUnknown:
+		return false
+	case n.open && k.isMap():
+		return len(n.keys) == 0
+	case n.open:
+		return len(n.elems) == 0
+	}
+
+	switch v := n.value.(type) {
+	case basetypes.BoolValue:
+		return !v.ValueBool()
+	case basetypes.NumberValue:
+		return v.ValueBigFloat().Sign() == 0
+	default:
+		return isEmpty(v)
+	}
+}
+
+// replaced returns src, ready to replace the value at path if ok, recording
+// where it came from.
+func (m *merger) replaced(ctx context.Context, path []string, src attr.Value, ok bool) (*node, bool, error) {
+	if !ok {
+		return nil, false, nil
+	}
+	m.origins.set(path, m.layer)
+	if m.tracing {
+		kind := "null"
+		if kindOf(src) != kindNull {
+			kind = leaf(src).kindName()
+		}
+		m.trace(ctx, "Value taken from argument", path, map[string]any{"kind": kind})
+	}
+	return m.prune(ctx, src), true, nil
+}
+
+// conflict returns an error if no_conflict is set and src would replace (or
+// be ignored in favour of) a different dst value.
+func (m *merger) conflict(ctx context.Context, path []string, dst *node, src attr.Value) error {
+	if !m.no_conflict || dst.kind() == kindNull {
+		return nil
+	}
+
+	dstValue, diags := dst.finish(ctx)
+	if diags.HasError() {
+		return diagsError(diags)
+	}
+	srcValue, diags := m.prune(ctx, src).finish(ctx)
+	if diags.HasError() {
+		return diagsError(diags)
+	}
+	if equalValues(dstValue, srcValue) {
+		return nil
+	}
+
+	layers := append(m.origins.sources(path), m.layer)
+	slices.Sort(layers)
+	return fmt.Errorf("conflicting values at %s from %s", m.describePath(path), m.origins.describeArguments(slices.Compact(layers)))
+}
+
+// describePath renders a path through the result in Terraform's syntax,
+// telling list indices and map keys from object attributes by the shape of
+// the values found along it. Where the result has no value yet, the shape is
+// that of the value being merged.
+func (m *merger) describePath(keys []string) string {
+	p := path.Empty()
+	n, src := m.root, unwrap(m.argument.Value)
+	for _, key := range keys {
+		kind := n.kind()
+		if !kind.isStructured() {
+			kind = kindOf(src)
+		}
+		switch i, err := strconv.Atoi(key); {
+		case kind.isList() && err == nil:
+			p, n = p.AtListIndex(i), n.element(i)
+			elems, _ := sequenceElements(src)
+			src = nil
+			if i >= 0 && i < len(elems) {
+				src = unwrap(elems[i])
+			}
+		case kind == kindMap:
+			p, n, src = p.AtMapKey(key), n.child(key), unwrap(attributes(src)[key])
+		default:
+			p, n, src = p.AtName(key), n.child(key), unwrap(attributes(src)[key])
+		}
+	}
+	return helpers.FormatPath(p)
+}
+
+// trace logs a decision about the value at path, naming the path and the
+// argument being merged. Callers check m.tracing first, so that nothing is
+// built when trace logs are off. Values themselves are never logged, as they
+// may be sensitive: only their kinds are.
+func (m *merger) trace(ctx context.Context, msg string, path []string, fields map[string]any) {
+	if fields == nil {
+		fields = make(map[string]any, 2)
+	}
+	fields["path"] = m.describePath(path)
+	fields["argument"] = m.argument.String()
+	tflog.Trace(ctx, msg, fields)
+}
+
+// element returns the node for index i in a list node, or nil if there is
+// none.
+func (n *node) element(i int) *node {
+	switch {
+	case n == nil:
+		return nil
+	case n.open:
+		if i < len(n.elems) {
+			return n.elems[i]
+		}
+		return nil
+	}
+	if elems, _ := sequenceElements(n.value); i < len(elems) {
+		return leaf(elems[i])
+	}
+	return nil
+}
+
+// diagsError returns the first error among diags.
+func diagsError(diags diag.Diagnostics) error {
+	d := diags.Errors()[0]
+	return fmt.Errorf("%s: %s", d.Summary(), d.Detail())
+}
+
+// isEmpty reports whether v is an empty string, list or map.
+func isEmpty(v attr.Value) bool {
+	switch k := kindOf(v); {
+	case k.isMap():
+		return len(attributes(v)) == 0
+	case k.isList():
+		elems, _ := sequenceElements(v)
+		return len(elems) == 0
+	default:
+		s, ok := v.(basetypes.StringValue)
+		return ok && s.ValueString() == ""
+	}
+}
+
+// listStrategy picks how two lists are combined: the strategy of a matching
+// path rule if it applies, otherwise the global list mode. An empty result
+// means src replaces dst.
+func (m *merger) listStrategy(rule string, dst *node, src attr.Value) string {
+	keyed := dst.isKeyedList() && leaf(src).isKeyedList()
+
+	switch {
+	case rule == "append", rule == "union", rule == "zip", rule == "prepend", rule == "prepend_union":
+		return rule
+	case strings.HasPrefix(rule, "merge_lists_by:") && keyed:
+		return rule
+	case m.merge_lists_by != "" && keyed:
+		return "merge_lists_by:" + m.merge_lists_by
+	case m.with_zip:
+		return "zip"
+	case m.with_prepend && m.with_union:
+		return "prepend_union"
+	case m.with_prepend:
+		return "prepend"
+	case m.with_union:
+		return "union"
+	case m.with_append:
+		return "append"
+	default:
+		return ""
+	}
+}
+
+// isKeyedList reports whether every element of a list node is a map (or an
+// unknown that may turn out to be one), making it eligible for merge_lists_by.
+func (n *node) isKeyedList() bool {
+	if n.open {
+		return !slices.ContainsFunc(n.elems, func(elem *node) bool {
+			k := elem.kind()
+			return !k.isMap() && k != kindUnknown
+		})
+	}
+
+	elems, _ := sequenceElements(n.value)
+	return !slices.ContainsFunc(elems, func(elem attr.Value) bool {
+		k := kindOf(elem)
+		return !k.isMap() && k != kindUnknown
+	})
+}
+
+// mergeLists combines the list src with the list node dst using the given
+// list strategy.
+func (m *merger) mergeLists(ctx context.Context, path []string, strategy string, dst *node, src attr.Value) (*node, error) {
+	srcElems, _ := sequenceElements(src)
+
+	if m.with_directives && slices.ContainsFunc(srcElems, isReplaceMarker) {
+		if m.tracing {
+			m.trace(ctx, "List replaced by $patch", path, nil)
+		}
+		m.origins.set(path, m.layer)
+		return m.prune(ctx, src), nil
+	}
+
+	dst.openList(ctx)
+	defer dst.generalize(ctx, src)
+
+	if m.knockout_prefix != "" {
+		srcElems = m.knockOutElements(path, dst, srcElems)
+	}
+
+	if m.tracing {
+		m.trace(ctx, "Lists combined", path, map[string]any{"strategy": strategy, "elements": len(dst.elems), "added": len(srcElems)})
+	}
+
+	switch strategy {
+	case "zip":
+		return dst, m.zipLists(ctx, path, dst, srcElems)
+
+	case "append":
+		added := m.pruneElements(ctx, srcElems)
+		m.origins.relist(path, fromFirst(indices(len(dst.elems)+len(added)), len(dst.elems)), m.layer)
+		dst.elems = append(dst.elems, added...)
+		return dst, nil
+
+	case "prepend":
+		added := m.pruneElements(ctx, srcElems)
+		m.origins.relist(path, fromSecond(indices(len(added)+len(dst.elems)), len(added)), m.layer)
+		dst.elems = append(added, dst.elems...)
+		return dst, nil
+
+	case "union", "prepend_union":
+		existing, diags := finishAll(ctx, dst.elems)
+		if diags.HasError() {
+			return dst, diagsError(diags)
+		}
+		added, diags := finishAll(ctx, m.pruneElements(ctx, srcElems))
+		if diags.HasError() {
+			return dst, diagsError(diags)
+		}
+
+		// which elements are duplicates, and so the length of the result,
+		// cannot be told while an unknown may turn out to equal another
+		if !distinguishable(ctx, slices.Concat(existing, added)) {
+			if m.tracing {
+				m.trace(ctx, "Unknown propagated", path, map[string]any{"reason": "an unknown element may be a duplicate"})
+			}
+			m.origins.set(path, m.layer)
+			return leaf(types.DynamicUnknown()), nil
+		}
+
+		var result []attr.Value
+		if strategy == "union" {
+			var from []int
+			result, from = unionSlices(ctx, existing, added)
+			m.origins.relist(path, fromFirst(from, len(existing)), m.layer)
+		} else {
+			var from []int
+			result, from = unionSlices(ctx, added, existing)
+			m.origins.relist(path, fromSecond(from, len(added)), m.layer)
+		}
+
+		dst.elems = make([]*node, len(result))
+		for i, v := range result {
+			dst.elems[i] = leaf(v)
+		}
+		return dst, nil
+
+	default:
+		return m.mergeListsByKey(ctx, path, strings.TrimPrefix(strategy, "merge_lists_by:"), dst, srcElems)
+	}
+}
+
+// finishAll returns the values of a list of nodes.
+func finishAll(ctx context.Context, nodes []*node) ([]attr.Value, diag.Diagnostics) {
+	values := make([]attr.Value, len(nodes))
+	for i, n := range nodes {
+		var diags diag.Diagnostics
+		if values[i], diags = n.finish(ctx); diags.HasError() {
+			return nil, diags
+		}
+	}
+	return values, nil
+}
+
+// zipLists merges the element at each index of src into the element at the
+// same index of the open list node dst, keeping the tail of whichever list is
+// longer.
+func (m *merger) zipLists(ctx context.Context, path []string, dst *node, srcElems []attr.Value) error {
+	result := make([]*node, max(len(dst.elems), len(srcElems)))
+	copy(result, dst.elems)
+
+	deleted := make(map[int]bool)
+
+	for i, srcElem := range srcElems {
+		srcElem = unwrap(srcElem)
+		if m.patch(srcElem) == "delete" {
+			deleted[i] = true
+			continue
+		}
+
+		elemPath := append(slices.Clip(path), strconv.Itoa(i))
+		if i >= len(dst.elems) {
+			result[i] = m.prune(ctx, srcElem)
+			m.origins.set(elemPath, m.layer)
+			continue
+		}
+
+		merged, ok, err := m.mergeValues(ctx, elemPath, result[i], srcElem)
+		if err != nil {
+			return err
+		}
+		if ok {
+			result[i] = merged
+		}
+	}
+
+	m.origins.relist(path, keptIndices(len(result), deleted), m.layer)
+	dst.elems = withoutIndices(result, deleted)
+	return nil
+}
+
+// mergeListsByKey deep-merges each element of src into the first element of
+// the open list node dst that shares its value for key, appending unmatched
+// elements. If any element or key value is unknown, matching cannot be
+// decided and the whole list becomes unknown (sticky unknown).
+func (m *merger) mergeListsByKey(ctx context.Context, path []string, key string, dst *node, srcElems []attr.Value) (*node, error) {
+	result := make([]*node, 0, len(dst.elems)+len(srcElems))
+	index := make(map[any]int)
+	deleted := make(map[int]bool)
+
+	for _, elem := range dst.elems {
+		keyValue := elem.lookup(key)
+		if elem.kind() == kindUnknown || kindOf(keyValue) == kindUnknown {
+			if m.tracing {
+				m.trace(ctx, "Unknown propagated", path, map[string]any{"reason": "an element or its " + key + " is unknown"})
+			}
+			return leaf(types.DynamicUnknown()), nil
+		}
+
+		if k, ok := matchKey(keyValue); ok {
+			if _, found := index[k]; !found {
+				index[k] = len(result)
+			}
+		}
+		result = append(result, elem)
+	}
+
+	for _, elem := range srcElems {
+		elem = unwrap(elem)
+		keyValue := attributes(elem)[key]
+		if kindOf(elem) == kindUnknown || kindOf(keyValue) == kindUnknown {
+			if m.tracing {
+				m.trace(ctx, "Unknown propagated", path, map[string]any{"reason": "an element or its " + key + " is unknown"})
+			}
+			return leaf(types.DynamicUnknown()), nil
+		}
+
+		// elements without a usable key never match
+		k, ok := matchKey(keyValue)
+
+		if m.patch(elem) == "delete" {
+			// delete the matching element, if any
+			if j, found := index[k]; ok && found {
+				deleted[j] = true
+			}
+			continue
+		}
+
+		if j, found := index[k]; ok && found {
+			result[j].openMap(ctx)
+			if err := m.mergeMaps(ctx, append(slices.Clip(path), strconv.Itoa(j)), result[j], elem); err != nil {
+				return dst, err
+			}
+			continue
+		}
+
+		if ok {
+			index[k] = len(result)
+		}
+		m.origins.set(append(slices.Clip(path), strconv.Itoa(len(result))), m.layer)
+		result = append(result, m.prune(ctx, elem))
+	}
+
+	m.origins.relist(path, keptIndices(len(result), deleted), m.layer)
+	dst.elems = withoutIndices(result, deleted)
+	return dst, nil
+}
+
+// lookup returns the value of key in a map node, if it is not open.
+func (n *node) lookup(key string) attr.Value {
+	if !n.open {
+		return attributes(n.value)[key]
+	}
+	if child := n.keys[key]; child != nil && !child.open {
+		return child.value
+	}
+	return nil
+}
+
+// knockedOut reports whether s carries the knockout prefix, returning the
+// key or list element it knocks out.
+func (m *merger) knockedOut(s string) (string, bool) {
+	if m.knockout_prefix == "" {
+		return "", false
+	}
+	return strings.CutPrefix(s, m.knockout_prefix)
+}
+
+// knockedOutElement is knockedOut for a list element, which must be a string.
+func (m *merger) knockedOutElement(v attr.Value) (string, bool) {
+	s, ok := unwrap(v).(basetypes.StringValue)
+	if !ok || s.IsNull() || s.IsUnknown() {
+		return "", false
+	}
+	return m.knockedOut(s.ValueString())
+}
+
+// knockOutElements removes from the open list node dst every string that src
+// knocks out, returning the elements of src less the knockout elements
+// themselves.
+func (m *merger) knockOutElements(path []string, dst *node, srcElems []attr.Value) []attr.Value {
+	targets := make(map[string]bool)
+	kept := make([]attr.Value, 0, len(srcElems))
+	for _, elem := range srcElems {
+		if target, ok := m.knockedOutElement(elem); ok {
+			targets[target] = true
+		} else {
+			kept = append(kept, elem)
+		}
+	}
+
+	removed := make(map[int]bool)
+	for i, elem := range dst.elems {
+		if s, ok := elem.value.(basetypes.StringValue); ok && !elem.open && !s.IsNull() && !s.IsUnknown() && targets[s.ValueString()] {
+			removed[i] = true
+		}
+	}
+	m.origins.relist(path, keptIndices(len(dst.elems), removed), m.layer)
+	dst.elems = withoutIndices(dst.elems, removed)
+
+	return kept
+}
+
+// prune returns a node for v with knockout keys and list elements, and any
+// directives, resolved at every depth, for values that are not merged with
+// anything. Subtrees with nothing to resolve are kept as they are.
+func (m *merger) prune(ctx context.Context, v attr.Value) *node {
+	v = unwrap(v)
+	if m.knockout_prefix == "" && !m.with_directives {
+		return leaf(v)
+	}
+
+	switch k := kindOf(v); {
+	case k.isMap():
+		attrs := attributes(v)
+		retain, retained := m.retainKeys(v)
+		keys := make(map[string]*node, len(attrs))
+		changed := false
+		for key, elem := range attrs {
+			_, knocked := m.knockedOut(key)
+			if knocked || m.with_directives && (isDirectiveKey(key) || retained && !retain[key]) || m.patch(elem) == "delete" {
+				changed = true
+				continue
+			}
+			keys[key] = m.prune(ctx, elem)
+			changed = changed || keys[key].open
+		}
+		if !changed {
+			return leaf(v)
+		}
+		return &node{open: true, shape: k, elemType: elemTypeOf(ctx, v), keys: keys}
+
+	case k.isList():
+		elems, _ := sequenceElements(v)
+		pruned := m.pruneElements(ctx, elems)
+		if len(pruned) == len(elems) && !slices.ContainsFunc(pruned, func(n *node) bool { return n.open }) {
+			return leaf(v)
+		}
+		return &node{open: true, shape: k, elemType: elemTypeOf(ctx, v), elems: pruned}
+
+	default:
+		return leaf(v)
+	}
+}
+
+// pruneElements prunes each element of a list, less those that are knocked
+// out or deleted, and any replace marker.
+func (m *merger) pruneElements(ctx context.Context, elems []attr.Value) []*node {
+	pruned := make([]*node, 0, len(elems))
+	for _, elem := range elems {
+		if _, ok := m.knockedOutElement(elem); ok {
+			continue
+		}
+		if m.patch(elem) == "delete" || m.with_directives && isReplaceMarker(elem) {
+			continue
+		}
+		pruned = append(pruned, m.prune(ctx, elem))
+	}
+	return pruned
+}
+
+// patch returns the $patch directive of a map, if directives are in use.
+func (m *merger) patch(v attr.Value) string {
+	if !m.with_directives {
+		return ""
+	}
+	return patchOf(v)
+}
+
+// retainKeys returns the keys listed by the $retainKeys directive of a map,
+// if directives are in use.
+func (m *merger) retainKeys(v attr.Value) (map[string]bool, bool) {
+	if !m.with_directives {
+		return nil, false
+	}
+	return retainKeysOf(v)
+}
+
+// withoutIndices returns a copy of s without the elements at the given indices.
+func withoutIndices[T any](s []T, indices map[int]bool) []T {
+	if len(indices) == 0 {
+		return s
+	}
+
+	result := make([]T, 0, len(s)-len(indices))
+	for i, elem := range s {
+		if !indices[i] {
+			result = append(result, elem)
+		}
+	}
+
+	return result
+}
+
+// unionSlices returns the unique elements of dst followed by those of src,
+// in the order they are first seen, along with the index of each within dst
+// and src taken together. Elements are compared by their canonical keys, so
+// that the union takes linear time.
+func unionSlices(ctx context.Context, dst, src []attr.Value) ([]attr.Value, []int) {
+	result := make([]attr.Value, 0, len(dst)+len(src))
+	from := make([]int, 0, len(dst)+len(src))
+	seen := make(map[string]bool, len(dst)+len(src))
+
+	for i, elem := range slices.Concat(dst, src) {
+		if key := helpers.Key(ctx, elem); !seen[key] {
+			seen[key] = true
+			result = append(result, elem)
+			from = append(from, i)
+		}
+	}
+
+	return result, from
+}
+
+// indices returns 0, 1, ... n-1.
+func indices(n int) []int {
+	from := make([]int, n)
+	for i := range from {
+		from[i] = i
+	}
+	return from
+}
+
+// fromFirst maps indices into dst followed by src, as returned by
+// unionSlices, to the form expected by origins.relist.
+func fromFirst(from []int, dstLen int) []int {
+	result := make([]int, len(from))
+	for i, j := range from {
+		if j >= dstLen {
+			j = -1
+		}
+		result[i] = j
+	}
+	return result
+}
+
+// fromSecond is fromFirst for indices into src followed by dst.
+func fromSecond(from []int, srcLen int) []int {
+	result := make([]int, len(from))
+	for i, j := range from {
+		result[i] = j - srcLen
+	}
+	return result
+}
+
+// equalValues reports whether two values are deeply equal, treating a map
+// and an object, or a list, set and tuple, with the same contents as equal,
+// and numbers as equal if they are numerically equal.
+func equalValues(a, b attr.Value) bool {
+	a, b = unwrap(a), unwrap(b)
+	ka, kb := kindOf(a), kindOf(b)
+
+	switch {
+	case ka == kindNull || kb == kindNull:
+		return ka == kb
+
+	case ka.isMap() && kb.isMap():
+		x, y := attributes(a), attributes(b)
+		if len(x) != len(y) {
+			return false
+		}
+		for key, elem := range x {
+			other, ok := y[key]
+			if !ok || !equalValues(elem, other) {
+				return false
+			}
+		}
+		return true
+
+	case ka.isList() && kb.isList():
+		x, _ := sequenceElements(a)
+		y, _ := sequenceElements(b)
+		return slices.EqualFunc(x, y, equalValues)
+
+	default:
+		if x, ok := a.(basetypes.NumberValue); ok && ka == kindScalar {
+			y, ok := b.(basetypes.NumberValue)
+			return ok && kb == kindScalar && x.ValueBigFloat().Cmp(y.ValueBigFloat()) == 0
+		}
+		return a.Equal(b)
+	}
+}
+
+// numberKey is the form of a number used to match list elements by key, so
+// that numerically equal keys match whatever their precision.
+type numberKey string
+
+// matchKey returns the value by which an element's key is matched, or false
+// if the key is not a usable scalar.
+func matchKey(v attr.Value) (any, bool) {
+	if kindOf(v) != kindScalar {
+		return nil, false
+	}
+
+	switch vv := unwrap(v).(type) {
+	case basetypes.StringValue:
+		return vv.ValueString(), true
+	case basetypes.BoolValue:
+		return vv.ValueBool(), true
+	case basetypes.NumberValue:
+		return numberKey(helpers.NumberKey(vv)), true
+	default:
+		return nil, false
+	}
+}
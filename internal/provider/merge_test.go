@@ -0,0 +1,262 @@
+// Copyright (c) Robin Breathe and contributors
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/function"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflogtest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/isometry/terraform-provider-deepmerge/internal/helpers"
+)
+
+func TestMergeTraceLogging(t *testing.T) {
+	t.Setenv("TF_LOG_PROVIDER_DEEPMERGE", "trace")
+
+	var output bytes.Buffer
+	ctx := tflogtest.RootLogger(context.Background(), &output)
+
+	object := func(vm map[string]attr.Value) types.Dynamic {
+		v, diags := helpers.NewObject(ctx, vm)
+		require.False(t, diags.HasError())
+		return types.DynamicValue(v)
+	}
+	tuple := func(vl ...attr.Value) attr.Value {
+		v, diags := helpers.NewTuple(ctx, vl)
+		require.False(t, diags.HasError())
+		return v
+	}
+
+	args := []attr.Value{
+		object(map[string]attr.Value{
+			"db":    object(map[string]attr.Value{"password": types.StringValue("hunter2"), "port": types.NumberValue(big.NewFloat(5432))}),
+			"cidrs": tuple(types.StringValue("10.0.0.0/8")),
+		}),
+		object(map[string]attr.Value{
+			"db":    object(map[string]attr.Value{"password": types.StringValue("correct horse")}),
+			"cidrs": tuple(types.StringValue("172.16.0.0/12")),
+		}),
+		types.DynamicValue(types.StringValue("append")),
+	}
+	argTypes := make([]attr.Type, len(args))
+	for i := range args {
+		argTypes[i] = types.DynamicType
+	}
+
+	req := function.RunRequest{Arguments: function.NewArgumentsData([]attr.Value{types.TupleValueMust(argTypes, args)})}
+	resp := &function.RunResponse{Result: function.NewResultData(types.DynamicUnknown())}
+	MergoFunction{}.Run(ctx, req, resp)
+	require.Nil(t, resp.Error)
+
+	assert.NotContains(t, output.String(), "hunter2")
+	assert.NotContains(t, output.String(), "correct horse")
+
+	entries, err := tflogtest.MultilineJSONDecode(&output)
+	require.NoError(t, err)
+
+	logged := func(level, message string, fields map[string]any) bool {
+		for _, entry := range entries {
+			if entry["@level"] != level || entry["@message"] != message {
+				continue
+			}
+			matched := true
+			for k, v := range fields {
+				matched = matched && entry[k] == v
+			}
+			if matched {
+				return true
+			}
+		}
+		return false
+	}
+
+	assert.True(t, logged("debug", "Merging argument", map[string]any{"argument": "argument 2", "keys": float64(2)}), "entries: %v", entries)
+	assert.True(t, logged("trace", "Value taken from argument", map[string]any{"path": "db.password", "argument": "argument 2", "kind": "string"}), "entries: %v", entries)
+	assert.True(t, logged("trace", "Lists combined", map[string]any{"path": "cidrs", "strategy": "append"}), "entries: %v", entries)
+}
+
+func TestMergeDescribePath(t *testing.T) {
+	ctx := context.Background()
+
+	object := func(vm map[string]attr.Value) attr.Value {
+		v, diags := helpers.NewObject(ctx, vm)
+		require.False(t, diags.HasError())
+		return v
+	}
+	tuple := func(vl ...attr.Value) attr.Value {
+		v, diags := helpers.NewTuple(ctx, vl)
+		require.False(t, diags.HasError())
+		return v
+	}
+	numbered := types.MapValueMust(types.StringType, map[string]attr.Value{"0": types.StringValue("x")})
+
+	for _, tc := range []struct {
+		name     string
+		dst, src attr.Value
+		keys     []string
+		want     string
+	}{
+		{"list in the result", object(map[string]attr.Value{"a": tuple(types.StringValue("x"))}), object(nil), []string{"a", "0"}, "a[0]"},
+		{"map in the result", object(map[string]attr.Value{"a": numbered}), object(nil), []string{"a", "0"}, `a["0"]`},
+		{"new list", object(nil), object(map[string]attr.Value{"a": tuple(types.StringValue("x"))}), []string{"a", "0"}, "a[0]"},
+		{"new map", object(nil), object(map[string]attr.Value{"a": numbered}), []string{"a", "0"}, `a["0"]`},
+	} {
+		m := &merger{root: leaf(tc.dst), argument: helpers.Argument{Value: types.DynamicValue(tc.src)}}
+		assert.Equal(t, tc.want, m.describePath(tc.keys), tc.name)
+	}
+}
+
+// benchmarkLayers generates a large configuration: a base layer defining
+// services, followed by overlays that each adjust a few of them, as
+// per-environment and per-region overrides would.
+func benchmarkLayers(services, overlays int) []types.Dynamic {
+	ctx := context.Background()
+
+	object := func(vm map[string]attr.Value) attr.Value {
+		v, diags := helpers.NewObject(ctx, vm)
+		if diags.HasError() {
+			panic(diags)
+		}
+		return v
+	}
+	tuple := func(vl ...attr.Value) attr.Value {
+		v, diags := helpers.NewTuple(ctx, vl)
+		if diags.HasError() {
+			panic(diags)
+		}
+		return v
+	}
+	number := func(n int) attr.Value {
+		return types.NumberValue(big.NewFloat(float64(n)))
+	}
+
+	base := make(map[string]attr.Value, services)
+	for i := range services {
+		tags := make(map[string]attr.Value)
+		for j := range 5 {
+			tags[fmt.Sprintf("tag%d", j)] = types.StringValue(fmt.Sprintf("value%d", j))
+		}
+		base[fmt.Sprintf("service%d", i)] = object(map[string]attr.Value{
+			"name":  types.StringValue(fmt.Sprintf("service%d", i)),
+			"port":  number(8000 + i),
+			"tags":  types.MapValueMust(types.StringType, tags),
+			"cidrs": tuple(types.StringValue("10.0.0.0/8"), types.StringValue("172.16.0.0/12")),
+			"health": object(map[string]attr.Value{
+				"path":     types.StringValue("/healthz"),
+				"interval": number(30),
+				"enabled":  types.BoolValue(true),
+			}),
+		})
+	}
+
+	layers := []types.Dynamic{types.DynamicValue(object(base))}
+	for n := range overlays {
+		overlay := make(map[string]attr.Value)
+		for i := n; i < services; i += services / 5 {
+			overlay[fmt.Sprintf("service%d", i)] = object(map[string]attr.Value{
+				"port":   number(9000 + n),
+				"tags":   types.MapValueMust(types.StringType, map[string]attr.Value{"layer": types.StringValue(fmt.Sprint(n))}),
+				"cidrs":  tuple(types.StringValue(fmt.Sprintf("192.168.%d.0/24", n))),
+				"health": object(map[string]attr.Value{"interval": number(n)}),
+			})
+		}
+		layers = append(layers, types.DynamicValue(object(overlay)))
+	}
+
+	return layers
+}
+
+func TestMergeUnknownTypeConstraint(t *testing.T) {
+	ctx := context.Background()
+
+	for _, tc := range []struct {
+		option string
+		want   attr.Value
+	}{
+		{"", types.DynamicUnknown()},
+		{"type:map(object({ port = optional(number, 80) }))", types.DynamicValue(types.MapUnknown(types.ObjectType{AttrTypes: map[string]attr.Type{"port": types.NumberType}}))},
+		{"type:map(any)", types.DynamicUnknown()},
+	} {
+		args := []types.Dynamic{types.DynamicValue(types.MapUnknown(types.StringType))}
+		if tc.option != "" {
+			args = append(args, types.DynamicValue(types.StringValue(tc.option)))
+		}
+
+		merged, _, err := mergeArguments(ctx, args, false)
+		require.Nil(t, err)
+		assert.True(t, merged.Equal(tc.want), "for %q: got %s", tc.option, merged)
+	}
+}
+
+func BenchmarkMerge(b *testing.B) {
+	ctx := context.Background()
+
+	for _, size := range []struct{ services, overlays int }{{100, 10}, {1000, 40}} {
+		layers := benchmarkLayers(size.services, size.overlays)
+
+		for _, mode := range []string{"override", "append"} {
+			name := fmt.Sprintf("%dx%d/%s", size.services, size.overlays, mode)
+
+			b.Run(name+"/untracked", func(b *testing.B) {
+				args := append(layers[:len(layers):len(layers)], types.DynamicValue(types.StringValue(mode)))
+				for b.Loop() {
+					if _, _, err := mergeArguments(ctx, args, false); err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+
+			// recording the origin of each value, as no_conflict and
+			// mergo_provenance do
+			b.Run(name+"/tracked", func(b *testing.B) {
+				args := append(layers[:len(layers):len(layers)], types.DynamicValue(types.StringValue(mode)))
+				for b.Loop() {
+					if _, _, err := mergeArguments(ctx, args, true); err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+		}
+	}
+}
+
+func BenchmarkUnion(b *testing.B) {
+	ctx := context.Background()
+
+	cidrs := func(offset, n int) types.Dynamic {
+		vl := make([]attr.Value, n)
+		for i := range vl {
+			vl[i] = types.StringValue(fmt.Sprintf("10.%d.%d.0/24", (offset+i)/256, (offset+i)%256))
+		}
+		value, diags := helpers.NewListOrTuple(ctx, vl, nil)
+		if diags.HasError() {
+			b.Fatal(diags)
+		}
+		return types.DynamicValue(types.ObjectValueMust(
+			map[string]attr.Type{"cidrs": value.Type(ctx)},
+			map[string]attr.Value{"cidrs": value},
+		))
+	}
+
+	for _, n := range []int{100, 10000} {
+		// the second list overlaps half of the first
+		args := []types.Dynamic{cidrs(0, n), cidrs(n/2, n), types.DynamicValue(types.StringValue("union"))}
+		b.Run(fmt.Sprint(n), func(b *testing.B) {
+			for b.Loop() {
+				if _, _, err := mergeArguments(ctx, args, false); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -8,12 +8,16 @@ import (
 	_ "embed"
 	"fmt"
 	"reflect"
+	"slices"
 	"strings"
 
-	"dario.cat/mergo"
+	"github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/terraform-plugin-framework/attr"
 	"github.com/hashicorp/terraform-plugin-framework/function"
+	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/types"
 	"github.com/hashicorp/terraform-plugin-framework/types/basetypes"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
 
 	"github.com/isometry/terraform-provider-deepmerge/internal/helpers"
 )
@@ -56,188 +60,305 @@ func (r MergoFunction) Run(ctx context.Context, req function.RunRequest, resp *f
 		return
 	}
 
-	if len(args) == 0 {
-		resp.Error = function.NewFuncError("at least one map must be provided")
+	merged, _, err := mergeArguments(ctx, args, false)
+	if err != nil {
+		resp.Error = err
 		return
 	}
 
-	objs := make([]types.Dynamic, 0)
-	opts := make([]func(*mergo.Config), 0)
-	with_override := true
-	no_null_override := false
-	with_append := false
-	with_union := false
+	resp.Error = function.ConcatFuncErrors(resp.Result.Set(ctx, &merged))
+}
+
+// mergeArguments merges the maps among the arguments of mergo, following the
+// options given by the other arguments. If track is set, it also returns the
+// origin of each merged value. If an argument is itself unknown, so is the
+// result, unless it is an object whose attributes can be merged in turn.
+func mergeArguments(ctx context.Context, args []types.Dynamic, track bool) (types.Dynamic, *origins, *function.FuncError) {
+	if len(args) == 0 {
+		return types.Dynamic{}, nil, function.NewFuncError("at least one map must be provided")
+	}
+
+	objs := make([]helpers.Argument, 0)
+	options := defaultMergeOptions()
+	layers := make([]mergeOptions, 0) // the options in force at each map
+	sequential := false
+	trailing := make([]int64, 0) // options given since the last map
+	with_directives := false
+	path_rules := make(map[string]string)
+	var constraint *typeConstraint // the type of the result, if given
+	constraintPosition := int64(0)
+	normalize_lists := false
+	nullable := make(map[int]bool) // maps that are unknown, so may turn out null
+	unknown := false               // whether the result must be unknown
+
+	// addMap handles a map argument, or a map within a list argument, which
+	// is either data to be merged or a control object. It reports false if
+	// the result must be unknown.
+	addMap := func(position, element int64, v attr.Value) (bool, *function.FuncError) {
+		argError := func(err error) *function.FuncError {
+			if element >= 0 {
+				return function.NewArgumentFuncError(position, fmt.Sprintf("element %d: %s", element, err))
+			}
+			return function.NewArgumentFuncError(position, err.Error())
+		}
+
+		if fields, ok := controlArgument(v, optionsKey); ok {
+			known, err := options.setFields(fields)
+			if err != nil {
+				return false, argError(err)
+			}
+			trailing = append(trailing, position)
+			return known, nil
+		}
+
+		if rules, ok := controlArgument(v, pathRulesKey); ok {
+			if rules.IsUnknown() {
+				return false, nil
+			}
+			if err := parsePathRules(rules, path_rules); err != nil {
+				return false, argError(err)
+			}
+			return true, nil
+		}
+
+		if v.IsNull() {
+			return true, nil
+		}
+
+		found, known, err := findDirectives(path.Empty(), v)
+		if err != nil {
+			return false, argError(err)
+		}
+		with_directives = with_directives || found
+		objs = append(objs, helpers.Argument{Position: position, Element: element, Value: types.DynamicValue(v)})
+		layers = append(layers, options)
+		trailing = trailing[:0]
+		return known, nil
+	}
 
 	for i, arg := range args {
 		if arg.IsNull() {
 			continue
 		}
 
-		// Handle unknown arguments - return unknown result
-		if arg.IsUnknown() || arg.IsUnderlyingValueUnknown() {
-			resp.Error = function.ConcatFuncErrors(resp.Result.Set(ctx, types.DynamicUnknown()))
-			return
+		// an unknown argument makes the result unknown, but the rest are
+		// still checked so that mistakes are reported during plan
+		if arg.IsUnknown() {
+			if helpers.Logging(hclog.Debug) {
+				tflog.Debug(ctx, "Argument is unknown, so the merged result is unknown", map[string]any{"argument": i + 1})
+			}
+			unknown = true
+			// it may be a map, so options before it may not trail the last
+			trailing = trailing[:0]
+			continue
 		}
 
 		value := arg.UnderlyingValue()
+		if value.IsUnknown() {
+			// an object of known type is merged as its unknown attributes
+			if expanded, ok := expandUnknown(ctx, value); ok {
+				nullable[len(objs)] = true
+				value = expanded
+			} else if mayBeArgument(value.Type(ctx)) {
+				if helpers.Logging(hclog.Debug) {
+					tflog.Debug(ctx, "Argument is unknown, so the merged result is unknown", map[string]any{"argument": i + 1})
+				}
+				unknown = true
+				// an unknown option can't be said to have no effect, but an
+				// unknown map means options before it may not trail the last
+				if kindOfType(value.Type(ctx)) != kindScalar {
+					trailing = trailing[:0]
+				}
+				continue
+			} else if k := kindOfType(value.Type(ctx)); k == kindList || k == kindTuple {
+				return types.Dynamic{}, nil, function.NewArgumentFuncError(int64(i), fmt.Sprintf("unsupported %s argument: its elements are not maps", typeName(value)))
+			}
+		}
 
 		switch vv := value.(type) {
 		case basetypes.StringValue:
-			switch option := vv.ValueString(); option {
-			case "no_override":
-				with_override = false
-
-			case "no_null_override":
-				no_null_override = true
-
-			case "override", "replace":
-				with_override = true
-
-			case "append", "append_lists":
-				opts = append(opts, mergo.WithAppendSlice)
-				with_append = true
-
-			case "union", "union_lists":
-				with_union = true
-
-			default:
-				resp.Error = function.NewArgumentFuncError(int64(i), "unrecognised option")
-				return
+			option := vv.ValueString()
+			if option == "sequential" {
+				sequential = true
+				break
 			}
+			if option == "normalize_lists" || option == "normalize" {
+				// like the type, normalization applies to the result
+				normalize_lists = true
+				break
+			}
+			if source, ok := strings.CutPrefix(option, typeConstraintPrefix); ok {
+				// the type applies to the result, wherever it is given
+				var err error
+				if constraint, err = parseTypeConstraint(source); err != nil {
+					return types.Dynamic{}, nil, function.NewArgumentFuncError(int64(i), err.Error())
+				}
+				constraintPosition = int64(i)
+				break
+			}
+			if !options.setString(option) {
+				return types.Dynamic{}, nil, function.NewArgumentFuncError(int64(i), unrecognised("option", option, optionNames).Error())
+			}
+			trailing = append(trailing, int64(i))
 
 		case basetypes.MapValue, basetypes.ObjectValue:
-			if !vv.IsNull() {
-				objs = append(objs, arg)
+			known, err := addMap(int64(i), -1, vv)
+			if err != nil {
+				return types.Dynamic{}, nil, err
+			}
+			unknown = unknown || !known
+
+		case basetypes.TupleValue, basetypes.ListValue:
+			// a list of maps expands in place into successive layers
+			elems, _ := sequenceElements(vv)
+			for j, elem := range elems {
+				if dv, ok := elem.(basetypes.DynamicValue); ok && !dv.IsNull() && !dv.IsUnknown() {
+					elem = dv.UnderlyingValue()
+				}
+
+				if elem.IsNull() {
+					continue
+				}
+
+				if elem.IsUnknown() {
+					if expanded, ok := expandUnknown(ctx, elem); ok {
+						nullable[len(objs)] = true
+						elem = expanded
+					} else if mayBeMap(elem.Type(ctx)) {
+						if helpers.Logging(hclog.Debug) {
+							tflog.Debug(ctx, "Argument element is unknown, so the merged result is unknown", map[string]any{"argument": i + 1, "element": j})
+						}
+						unknown = true
+						trailing = trailing[:0]
+						continue
+					}
+				}
+
+				switch elem.(type) {
+				case basetypes.MapValue, basetypes.ObjectValue:
+					known, err := addMap(int64(i), int64(j), elem)
+					if err != nil {
+						return types.Dynamic{}, nil, err
+					}
+					unknown = unknown || !known
+				default:
+					return types.Dynamic{}, nil, function.NewArgumentFuncError(int64(i), fmt.Sprintf("unsupported %s argument: element %d is a %s, not a map", typeName(vv), j, typeName(elem)))
+				}
 			}
 
 		default:
-			typeName := strings.ToLower(strings.TrimSuffix(reflect.TypeOf(value).Name(), "Value"))
-			resp.Error = function.NewArgumentFuncError(int64(i), fmt.Sprintf("unsupported %s argument", typeName))
-			return
+			return types.Dynamic{}, nil, function.NewArgumentFuncError(int64(i), fmt.Sprintf("unsupported %s argument", typeName(value)))
 		}
 	}
 
-	if with_override {
-		opts = append(opts, mergo.WithOverride)
+	if sequential && len(trailing) > 0 {
+		return types.Dynamic{}, nil, function.NewArgumentFuncError(trailing[0], "option follows the last map, so has no effect in sequential mode")
 	}
 
-	if no_null_override || with_union {
-		opts = append(opts, mergo.WithTransformers(customTransformer{
-			with_append:        with_append,
-			with_union:         with_union,
-			with_null_override: !no_null_override,
-		}))
-	}
-
-	merged, diags := helpers.Mergo(ctx, objs, opts...)
-	if diags.HasError() {
-		resp.Error = function.FuncErrorFromDiags(ctx, diags)
-		return
+	if unknown {
+		return unknownResult(ctx, constraint), nil, nil
 	}
 
-	resp.Error = function.ConcatFuncErrors(resp.Result.Set(ctx, &merged))
-}
-
-type customTransformer struct {
-	with_append        bool
-	with_union         bool
-	with_null_override bool
-}
-
-func (t customTransformer) Transformer(typ reflect.Type) func(dst, src reflect.Value) error {
-	if typ.Kind() == reflect.Map {
-		return func(dst, src reflect.Value) error {
-			deepMergeMaps(dst, src, t.with_append, t.with_union, t.with_null_override)
-			return nil
+	// by default, options apply to every map wherever they appear
+	if !sequential {
+		for i := range layers {
+			layers[i] = options
 		}
 	}
-	return nil
-}
 
-func deepMergeMaps(dst, src reflect.Value, appendSlice bool, uniqueSlice bool, nullOverride bool) reflect.Value {
-	for _, key := range src.MapKeys() {
-		srcElem := src.MapIndex(key)
-		dstElem := dst.MapIndex(key)
+	m := merger{
+		with_directives: with_directives,
+		path_rules:      newPathRules(path_rules),
+		tracing:         helpers.Logging(hclog.Trace),
+	}
+	if track || slices.ContainsFunc(layers, func(o mergeOptions) bool { return o.no_conflict }) {
+		m.origins = newOrigins(objs)
+	}
 
-		// Unwrap the interfaces of srcElem and dstElem
-		if srcElem.Kind() == reflect.Interface {
-			srcElem = srcElem.Elem()
+	// each map is merged in turn into the result, with the options in force
+	// at that map
+	var result *node
+	uncertain := make(map[string]bool) // keys that only unknown maps supply
+	for i, obj := range objs {
+		value := obj.Value.UnderlyingValue()
+		if result == nil {
+			result = &node{open: true, shape: kindOf(value), elemType: elemTypeOf(ctx, value), keys: make(map[string]*node)}
 		}
 
-		if dstElem.Kind() == reflect.Interface {
-			dstElem = dstElem.Elem()
-		}
+		m.mergeOptions = layers[i]
+		m.layer, m.root, m.argument = int64(i), result, obj
 
-		// BIDIRECTIONAL STICKY UNKNOWN: If either side is unknown, result is unknown
-		srcIsUnknown := srcElem.IsValid() && isUnknownSentinel(srcElem)
-		dstIsUnknown := dstElem.IsValid() && isUnknownSentinel(dstElem)
+		if helpers.Logging(hclog.Debug) {
+			tflog.Debug(ctx, "Merging argument", map[string]any{"argument": obj.String(), "keys": len(attributes(value)), "unknown": nullable[i]})
+		}
 
-		if srcIsUnknown || dstIsUnknown {
-			// Prefer src's sentinel if available (more recent type info), otherwise keep dst's
-			if srcIsUnknown {
-				dst.SetMapIndex(key, srcElem)
+		for key := range attributes(value) {
+			switch _, knockout := m.knockedOut(key); {
+			case !nullable[i]:
+				delete(uncertain, key)
+			case knockout:
+				// whether the key is knocked out depends on the unknown
+				if helpers.Logging(hclog.Debug) {
+					tflog.Debug(ctx, "Knockout key of an unknown argument makes the merged result unknown", map[string]any{"argument": obj.String()})
+				}
+				return unknownResult(ctx, constraint), nil, nil
+			case result.keys[key] == nil:
+				uncertain[key] = true
 			}
-			// If only dst is unknown, it stays (already in dst)
-			continue
 		}
+		if err := m.mergeMaps(ctx, nil, result, value); err != nil {
+			return types.Dynamic{}, nil, function.NewArgumentFuncError(obj.Position, fmt.Sprintf("Error merging %s: %s", obj, err))
+		}
+	}
+	if result == nil {
+		result = &node{open: true, shape: kindObject}
+	}
 
-		if srcElem.Kind() == reflect.Map && dstElem.Kind() == reflect.Map {
-			// recursive call
-			newValue := deepMergeMaps(dstElem, srcElem, appendSlice, uniqueSlice, nullOverride)
-			dst.SetMapIndex(key, newValue)
-		} else if !srcElem.IsValid() { // src value is null
-			if !nullOverride && dstElem.IsValid() {
-				continue // no_null_override: keep the existing value
+	// the keys are not known if any might not be there
+	for key := range uncertain {
+		if result.keys[key] != nil {
+			if helpers.Logging(hclog.Debug) {
+				tflog.Debug(ctx, "Key only an unknown argument supplies makes the merged result unknown", map[string]any{"path": m.describePath([]string{key})})
 			}
-			// preserve the null key — an invalid Value would delete it (issue #138)
-			dst.SetMapIndex(key, reflect.Zero(dst.Type().Elem()))
-		} else if srcElem.Kind() == reflect.Slice && dstElem.Kind() == reflect.Slice && uniqueSlice { // handle union
-			dst.SetMapIndex(key, unionSlices(dstElem, srcElem))
-		} else if srcElem.Kind() == reflect.Slice && dstElem.Kind() == reflect.Slice && appendSlice { // handle append
-			dst.SetMapIndex(key, reflect.AppendSlice(dstElem, srcElem))
-		} else {
-			dst.SetMapIndex(key, srcElem)
+			return unknownResult(ctx, constraint), nil, nil
 		}
 	}
 
-	return dst
-}
-
-// isUnknownSentinel checks if a reflect.Value contains an UnknownSentinel.
-func isUnknownSentinel(v reflect.Value) bool {
-	if !v.IsValid() || !v.CanInterface() {
-		return false
+	value, diags := result.finish(ctx)
+	if diags.HasError() {
+		return types.Dynamic{}, nil, function.FuncErrorFromDiags(ctx, diags)
 	}
-	return helpers.IsUnknownSentinel(v.Interface())
-}
-
-func unionSlices(dst, src reflect.Value) reflect.Value {
-	result := reflect.MakeSlice(dst.Type(), 0, dst.Len()+src.Len())
+	merged := types.DynamicValue(value)
 
-	// Add elements from dst (preserving order)
-	for i := 0; i < dst.Len(); i++ {
-		if !containsElement(result, dst.Index(i)) {
-			result = reflect.Append(result, dst.Index(i))
+	if normalize_lists && !merged.IsUnknown() {
+		normalized, diags := helpers.NormalizeLists(ctx, merged.UnderlyingValue())
+		if diags.HasError() {
+			return types.Dynamic{}, nil, function.FuncErrorFromDiags(ctx, diags)
 		}
+		merged = types.DynamicValue(normalized)
 	}
 
-	// Add new elements from src
-	for i := 0; i < src.Len(); i++ {
-		if !containsElement(result, src.Index(i)) {
-			result = reflect.Append(result, src.Index(i))
+	if constraint != nil && !merged.IsUnknown() {
+		converted, err := constraint.convert(ctx, merged.UnderlyingValue())
+		if err != nil {
+			return types.Dynamic{}, nil, function.NewArgumentFuncError(constraintPosition, err.Error())
 		}
+		merged = types.DynamicValue(converted)
 	}
 
-	return result
+	return merged, m.origins, nil
 }
 
-// containsElement checks if a slice contains a specific element using reflect.DeepEqual.
-func containsElement(slice, elem reflect.Value) bool {
-	elemInterface := elem.Interface()
-	for i := 0; i < slice.Len(); i++ {
-		if reflect.DeepEqual(slice.Index(i).Interface(), elemInterface) {
-			return true
-		}
+// unknownResult is the result of a merge that can't be known yet: unknown,
+// and of the constrained type if one was given.
+func unknownResult(ctx context.Context, constraint *typeConstraint) types.Dynamic {
+	if constraint == nil {
+		return types.DynamicUnknown()
 	}
-	return false
+	return constraint.unknown(ctx)
+}
+
+// typeName names the type of a value, e.g. "tuple" for a TupleValue.
+func typeName(v attr.Value) string {
+	return strings.ToLower(strings.TrimSuffix(reflect.TypeOf(v).Name(), "Value"))
 }
@@ -4,6 +4,7 @@
 package provider
 
 import (
+	"math/big"
 	"regexp"
 	"testing"
 
@@ -456,6 +457,73 @@ func TestMergoFunction_NoOverride(t *testing.T) {
 	})
 }
 
+func TestMergoFunction_NoOverrideZeroValues(t *testing.T) {
+	resource.UnitTest(t, resource.TestCase{
+		TerraformVersionChecks: []tfversion.TerraformVersionCheck{
+			tfversion.SkipBelow(version.Must(version.NewVersion("1.8.0"))),
+		},
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			// Test: Zero values are filled in by later values
+			{
+				Config: `
+				locals {
+					map1 = { s = "", b = false, n = 0, l = [], m = {}, kept = "base" }
+					map2 = { s = "x", b = true, n = 5, l = ["y"], m = "z", kept = "later" }
+				}
+				output "test" {
+					value = provider::deepmerge::mergo(local.map1, local.map2, "no_override")
+				}
+				`,
+				ConfigStateChecks: []statecheck.StateCheck{
+					statecheck.ExpectKnownOutputValue("test",
+						knownvalue.ObjectExact(map[string]knownvalue.Check{
+							"s":    knownvalue.StringExact("x"),
+							"b":    knownvalue.Bool(true),
+							"n":    knownvalue.Int64Exact(5),
+							"l":    knownvalue.ListExact([]knownvalue.Check{knownvalue.StringExact("y")}),
+							"m":    knownvalue.StringExact("z"),
+							"kept": knownvalue.StringExact("base"),
+						}),
+					),
+				},
+			},
+			// Test: Zero values are filled in alongside a list mode too
+			{
+				Config: `
+				output "test" {
+					value = provider::deepmerge::mergo({ s = "", n = 1 }, { s = "x", n = 2 }, "no_override", "union")
+				}
+				`,
+				ConfigStateChecks: []statecheck.StateCheck{
+					statecheck.ExpectKnownOutputValue("test",
+						knownvalue.ObjectExact(map[string]knownvalue.Check{
+							"s": knownvalue.StringExact("x"),
+							"n": knownvalue.Int64Exact(1),
+						}),
+					),
+				},
+			},
+			// Test: A no_override path rule keeps even zero values
+			{
+				Config: `
+				output "test" {
+					value = provider::deepmerge::mergo({ s = "", b = false }, { s = "x", b = true }, { "$rules" = { s = "no_override" } }, "no_override")
+				}
+				`,
+				ConfigStateChecks: []statecheck.StateCheck{
+					statecheck.ExpectKnownOutputValue("test",
+						knownvalue.ObjectExact(map[string]knownvalue.Check{
+							"s": knownvalue.StringExact(""),
+							"b": knownvalue.Bool(true),
+						}),
+					),
+				},
+			},
+		},
+	})
+}
+
 func TestMergoFunction_Null(t *testing.T) {
 	resource.UnitTest(t, resource.TestCase{
 		TerraformVersionChecks: []tfversion.TerraformVersionCheck{
@@ -949,6 +1017,30 @@ func TestMergoFunction_InvalidType(t *testing.T) {
 				`,
 				ExpectError: regexp.MustCompile(`unsupported set argument`),
 			},
+			{
+				Config: `
+				output "test" {
+					value = provider::deepmerge::mergo({ a = [1] }, { a = [2] }, "apend")
+				}
+				`,
+				ExpectError: regexp.MustCompile(`unrecognised option "apend", did\s+you\s+mean\s+"append"\?`),
+			},
+			{
+				Config: `
+				output "test" {
+					value = provider::deepmerge::mergo({ a = [1] }, { a = [2] }, "union_list")
+				}
+				`,
+				ExpectError: regexp.MustCompile(`did\s+you\s+mean\s+"union_lists"\?`),
+			},
+			{
+				Config: `
+				output "test" {
+					value = provider::deepmerge::mergo({ a = [1] }, "merge_list_by:id")
+				}
+				`,
+				ExpectError: regexp.MustCompile(`did\s+you\s+mean\s+"merge_lists_by:id"\?`),
+			},
 		},
 	})
 }
@@ -1348,3 +1440,1318 @@ func TestMergoFunction_NestedVariableOption(t *testing.T) {
 		},
 	})
 }
+
+func TestMergoFunction_MergeListsBy(t *testing.T) {
+	resource.UnitTest(t, resource.TestCase{
+		TerraformVersionChecks: []tfversion.TerraformVersionCheck{
+			tfversion.SkipBelow(version.Must(version.NewVersion("1.8.0"))),
+		},
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: `
+				locals {
+					base = {
+						containers = [
+							{ name = "app", image = "app:1.0", ports = [8080] },
+							{ name = "sidecar", image = "proxy:2.1" },
+						]
+						tags = ["a"]
+					}
+					overrides = {
+						containers = [
+							{ name = "app", image = "app:1.1", ports = [9090] },
+							{ name = "metrics", image = "exporter:0.9" },
+						]
+						tags = ["b"]
+					}
+				}
+				output "test" {
+					value = provider::deepmerge::mergo(local.base, local.overrides, "merge_lists_by:name")
+				}
+				`,
+				ConfigStateChecks: []statecheck.StateCheck{
+					statecheck.ExpectKnownOutputValue("test",
+						knownvalue.MapExact(map[string]knownvalue.Check{
+							"containers": knownvalue.ListExact([]knownvalue.Check{
+								knownvalue.MapExact(map[string]knownvalue.Check{
+									"name":  knownvalue.StringExact("app"),
+									"image": knownvalue.StringExact("app:1.1"),
+									"ports": knownvalue.ListExact([]knownvalue.Check{
+										knownvalue.Int64Exact(9090),
+									}),
+								}),
+								knownvalue.MapExact(map[string]knownvalue.Check{
+									"name":  knownvalue.StringExact("sidecar"),
+									"image": knownvalue.StringExact("proxy:2.1"),
+								}),
+								knownvalue.MapExact(map[string]knownvalue.Check{
+									"name":  knownvalue.StringExact("metrics"),
+									"image": knownvalue.StringExact("exporter:0.9"),
+								}),
+							}),
+							// lists of scalars fall back to the default (replace)
+							"tags": knownvalue.ListExact([]knownvalue.Check{
+								knownvalue.StringExact("b"),
+							}),
+						}),
+					),
+				},
+			},
+			{
+				Config: `
+				locals {
+					base = {
+						statements = [
+							{ sid = "read", actions = ["s3:GetObject"] },
+						]
+					}
+					overrides = {
+						statements = [
+							{ sid = "read", actions = ["s3:ListBucket"] },
+							{ actions = ["s3:PutObject"] },
+						]
+					}
+				}
+				output "test" {
+					value = provider::deepmerge::mergo(local.base, local.overrides, "merge_lists_by:sid", "append")
+				}
+				`,
+				ConfigStateChecks: []statecheck.StateCheck{
+					statecheck.ExpectKnownOutputValue("test",
+						knownvalue.MapExact(map[string]knownvalue.Check{
+							"statements": knownvalue.ListExact([]knownvalue.Check{
+								knownvalue.MapExact(map[string]knownvalue.Check{
+									"sid": knownvalue.StringExact("read"),
+									"actions": knownvalue.ListExact([]knownvalue.Check{
+										knownvalue.StringExact("s3:GetObject"),
+										knownvalue.StringExact("s3:ListBucket"),
+									}),
+								}),
+								knownvalue.MapExact(map[string]knownvalue.Check{
+									"actions": knownvalue.ListExact([]knownvalue.Check{
+										knownvalue.StringExact("s3:PutObject"),
+									}),
+								}),
+							}),
+						}),
+					),
+				},
+			},
+			{
+				Config: `
+				output "test" {
+					value = provider::deepmerge::mergo({}, "merge_lists_by:")
+				}
+				`,
+				ExpectError: regexp.MustCompile(`unrecognised option`),
+			},
+		},
+	})
+}
+
+func TestMergoFunction_ZipLists(t *testing.T) {
+	resource.UnitTest(t, resource.TestCase{
+		TerraformVersionChecks: []tfversion.TerraformVersionCheck{
+			tfversion.SkipBelow(version.Must(version.NewVersion("1.8.0"))),
+		},
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: `
+				locals {
+					base = {
+						ingress = [
+							{ host = "a", rules = ["r1", "r2"] },
+							{ host = "b" },
+						]
+						ports = [80, 443, 8080]
+					}
+					overrides = {
+						ingress = [
+							{ rules = ["R1"] },
+						]
+						ports = [81, null, 8081, 9090]
+					}
+				}
+				output "test" {
+					value = provider::deepmerge::mergo(local.base, local.overrides, "zip_lists")
+				}
+				`,
+				ConfigStateChecks: []statecheck.StateCheck{
+					statecheck.ExpectKnownOutputValue("test",
+						knownvalue.MapExact(map[string]knownvalue.Check{
+							"ingress": knownvalue.ListExact([]knownvalue.Check{
+								knownvalue.MapExact(map[string]knownvalue.Check{
+									"host": knownvalue.StringExact("a"),
+									"rules": knownvalue.ListExact([]knownvalue.Check{
+										knownvalue.StringExact("R1"),
+										knownvalue.StringExact("r2"),
+									}),
+								}),
+								knownvalue.MapExact(map[string]knownvalue.Check{
+									"host": knownvalue.StringExact("b"),
+								}),
+							}),
+							"ports": knownvalue.ListExact([]knownvalue.Check{
+								knownvalue.Int64Exact(81),
+								knownvalue.Null(),
+								knownvalue.Int64Exact(8081),
+								knownvalue.Int64Exact(9090),
+							}),
+						}),
+					),
+				},
+			},
+			{
+				Config: `
+				locals {
+					base      = { ports = [80, 443, 8080] }
+					overrides = { ports = [81, null] }
+				}
+				output "test" {
+					value = provider::deepmerge::mergo(local.base, local.overrides, "zip", "no_null_override")
+				}
+				`,
+				ConfigStateChecks: []statecheck.StateCheck{
+					statecheck.ExpectKnownOutputValue("test",
+						knownvalue.MapExact(map[string]knownvalue.Check{
+							"ports": knownvalue.ListExact([]knownvalue.Check{
+								knownvalue.Int64Exact(81),
+								knownvalue.Int64Exact(443),
+								knownvalue.Int64Exact(8080),
+							}),
+						}),
+					),
+				},
+			},
+		},
+	})
+}
+
+func TestMergoFunction_PathRules(t *testing.T) {
+	resource.UnitTest(t, resource.TestCase{
+		TerraformVersionChecks: []tfversion.TerraformVersionCheck{
+			tfversion.SkipBelow(version.Must(version.NewVersion("1.8.0"))),
+		},
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: `
+				locals {
+					base = {
+						firewall = {
+							rules         = ["ssh"]
+							allowed_cidrs = ["10.0.0.0/8"]
+						}
+						spec = {
+							web = { ports = [80] }
+							api = { ports = [8080] }
+						}
+						tags   = ["a", "b"]
+						policy = { x = 1, y = 2 }
+					}
+					overrides = {
+						firewall = {
+							rules         = ["https"]
+							allowed_cidrs = ["192.168.0.0/16"]
+						}
+						spec = {
+							web = { ports = [443] }
+							api = { ports = [8443] }
+						}
+						tags   = ["b", "c"]
+						policy = { z = 3 }
+					}
+				}
+				output "test" {
+					value = provider::deepmerge::mergo(local.base, local.overrides, "append", {
+						"$rules" = {
+							"spec.*.ports"           = "append"
+							"spec.api.ports"         = "no_override"
+							"tags"                   = "union"
+							"policy"                 = "replace"
+							"firewall.allowed_cidrs" = "replace"
+						}
+					})
+				}
+				`,
+				ConfigStateChecks: []statecheck.StateCheck{
+					statecheck.ExpectKnownOutputValue("test",
+						knownvalue.MapExact(map[string]knownvalue.Check{
+							"firewall": knownvalue.MapExact(map[string]knownvalue.Check{
+								"rules": knownvalue.ListExact([]knownvalue.Check{
+									knownvalue.StringExact("ssh"),
+									knownvalue.StringExact("https"),
+								}),
+								"allowed_cidrs": knownvalue.ListExact([]knownvalue.Check{
+									knownvalue.StringExact("192.168.0.0/16"),
+								}),
+							}),
+							"spec": knownvalue.MapExact(map[string]knownvalue.Check{
+								"web": knownvalue.MapExact(map[string]knownvalue.Check{
+									"ports": knownvalue.ListExact([]knownvalue.Check{
+										knownvalue.Int64Exact(80),
+										knownvalue.Int64Exact(443),
+									}),
+								}),
+								"api": knownvalue.MapExact(map[string]knownvalue.Check{
+									"ports": knownvalue.ListExact([]knownvalue.Check{
+										knownvalue.Int64Exact(8080),
+									}),
+								}),
+							}),
+							"tags": knownvalue.ListExact([]knownvalue.Check{
+								knownvalue.StringExact("a"),
+								knownvalue.StringExact("b"),
+								knownvalue.StringExact("c"),
+							}),
+							"policy": knownvalue.MapExact(map[string]knownvalue.Check{
+								"z": knownvalue.Int64Exact(3),
+							}),
+						}),
+					),
+				},
+			},
+			{
+				Config: `
+				output "test" {
+					value = provider::deepmerge::mergo({}, { "$rules" = { tags = "bogus" } })
+				}
+				`,
+				ExpectError: regexp.MustCompile(`unrecognised strategy "bogus"`),
+			},
+		},
+	})
+}
+
+func TestMergoFunction_NoOverrideWithListModes(t *testing.T) {
+	resource.UnitTest(t, resource.TestCase{
+		TerraformVersionChecks: []tfversion.TerraformVersionCheck{
+			tfversion.SkipBelow(version.Must(version.NewVersion("1.8.0"))),
+		},
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			// Test: Earlier values are kept while lists are combined
+			{
+				Config: `
+				locals {
+					map1 = { a = 1, tags = ["x"] }
+					map2 = { a = 2, b = 3, tags = ["y", "x"] }
+				}
+				output "test" {
+					value = provider::deepmerge::mergo(local.map1, local.map2, "no_override", "union")
+				}
+				`,
+				ConfigStateChecks: []statecheck.StateCheck{
+					statecheck.ExpectKnownOutputValue("test",
+						knownvalue.MapExact(map[string]knownvalue.Check{
+							"a": knownvalue.Int64Exact(1),
+							"b": knownvalue.Int64Exact(3),
+							"tags": knownvalue.ListExact([]knownvalue.Check{
+								knownvalue.StringExact("x"),
+								knownvalue.StringExact("y"),
+							}),
+						}),
+					),
+				},
+			},
+			// Test: Earlier values are kept with no_null_override too
+			{
+				Config: `
+				output "test" {
+					value = provider::deepmerge::mergo({ a = 1, b = "x" }, { a = 2, b = null, c = 3 }, "no_override", "no_null_override")
+				}
+				`,
+				ConfigStateChecks: []statecheck.StateCheck{
+					statecheck.ExpectKnownOutputValue("test",
+						knownvalue.ObjectExact(map[string]knownvalue.Check{
+							"a": knownvalue.Int64Exact(1),
+							"b": knownvalue.StringExact("x"),
+							"c": knownvalue.Int64Exact(3),
+						}),
+					),
+				},
+			},
+		},
+	})
+}
+
+func TestMergoFunction_Knockout(t *testing.T) {
+	resource.UnitTest(t, resource.TestCase{
+		TerraformVersionChecks: []tfversion.TerraformVersionCheck{
+			tfversion.SkipBelow(version.Must(version.NewVersion("1.8.0"))),
+		},
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: `
+				locals {
+					defaults = {
+						debug    = true
+						logging  = { level = "info", format = "json" }
+						features = ["metrics", "tracing", "profiling"]
+					}
+					overrides = {
+						"--debug" = null
+						logging   = { "--format" = null }
+						features  = ["--profiling", "audit"]
+						extra     = { "--ignored" = 1, values = ["--x", "y"] }
+					}
+				}
+				output "test" {
+					value = provider::deepmerge::mergo(local.defaults, local.overrides, "append", "no_null_override", "knockout:--")
+				}
+				`,
+				ConfigStateChecks: []statecheck.StateCheck{
+					statecheck.ExpectKnownOutputValue("test",
+						knownvalue.MapExact(map[string]knownvalue.Check{
+							"logging": knownvalue.MapExact(map[string]knownvalue.Check{
+								"level": knownvalue.StringExact("info"),
+							}),
+							"features": knownvalue.ListExact([]knownvalue.Check{
+								knownvalue.StringExact("metrics"),
+								knownvalue.StringExact("tracing"),
+								knownvalue.StringExact("audit"),
+							}),
+							"extra": knownvalue.MapExact(map[string]knownvalue.Check{
+								"values": knownvalue.ListExact([]knownvalue.Check{
+									knownvalue.StringExact("y"),
+								}),
+							}),
+						}),
+					),
+				},
+			},
+			{
+				Config: `
+				locals {
+					defaults  = { tags = ["a", "b", "c"] }
+					overrides = { tags = ["--b", "d", "a"] }
+				}
+				output "test" {
+					value = provider::deepmerge::mergo(local.defaults, local.overrides, "union", "knockout:--")
+				}
+				`,
+				ConfigStateChecks: []statecheck.StateCheck{
+					statecheck.ExpectKnownOutputValue("test",
+						knownvalue.MapExact(map[string]knownvalue.Check{
+							"tags": knownvalue.ListExact([]knownvalue.Check{
+								knownvalue.StringExact("a"),
+								knownvalue.StringExact("c"),
+								knownvalue.StringExact("d"),
+							}),
+						}),
+					),
+				},
+			},
+		},
+	})
+}
+
+func TestMergoFunction_Prepend(t *testing.T) {
+	resource.UnitTest(t, resource.TestCase{
+		TerraformVersionChecks: []tfversion.TerraformVersionCheck{
+			tfversion.SkipBelow(version.Must(version.NewVersion("1.8.0"))),
+		},
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: `
+				locals {
+					base      = { path = ["/usr/local/bin", "/usr/bin"] }
+					overrides = { path = ["/opt/bin", "/usr/bin"] }
+				}
+				output "test" {
+					value = provider::deepmerge::mergo(local.base, local.overrides, "prepend")
+				}
+				`,
+				ConfigStateChecks: []statecheck.StateCheck{
+					statecheck.ExpectKnownOutputValue("test",
+						knownvalue.MapExact(map[string]knownvalue.Check{
+							"path": knownvalue.ListExact([]knownvalue.Check{
+								knownvalue.StringExact("/opt/bin"),
+								knownvalue.StringExact("/usr/bin"),
+								knownvalue.StringExact("/usr/local/bin"),
+								knownvalue.StringExact("/usr/bin"),
+							}),
+						}),
+					),
+				},
+			},
+			{
+				Config: `
+				locals {
+					base      = { path = ["/usr/local/bin", "/usr/bin"] }
+					overrides = { path = ["/opt/bin", "/usr/bin"] }
+				}
+				output "test" {
+					value = provider::deepmerge::mergo(local.base, local.overrides, "prepend_union")
+				}
+				`,
+				ConfigStateChecks: []statecheck.StateCheck{
+					statecheck.ExpectKnownOutputValue("test",
+						knownvalue.MapExact(map[string]knownvalue.Check{
+							"path": knownvalue.ListExact([]knownvalue.Check{
+								knownvalue.StringExact("/opt/bin"),
+								knownvalue.StringExact("/usr/bin"),
+								knownvalue.StringExact("/usr/local/bin"),
+							}),
+						}),
+					),
+				},
+			},
+		},
+	})
+}
+
+func TestMergoFunction_PatchDirectives(t *testing.T) {
+	resource.UnitTest(t, resource.TestCase{
+		TerraformVersionChecks: []tfversion.TerraformVersionCheck{
+			tfversion.SkipBelow(version.Must(version.NewVersion("1.8.0"))),
+		},
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: `
+				locals {
+					base = {
+						logging   = { level = "info", format = "json" }
+						legacy    = { enabled = true }
+						resources = { cpu = "100m", memory = "128Mi", gpu = 1 }
+						hosts     = ["a", "b"]
+					}
+					overrides = {
+						logging   = { "$patch" = "replace", level = "debug" }
+						legacy    = { "$patch" = "delete" }
+						resources = { "$retainKeys" = ["cpu", "memory"], memory = "256Mi" }
+						hosts     = [{ "$patch" = "replace" }, "c"]
+					}
+				}
+				output "test" {
+					value = provider::deepmerge::mergo(local.base, local.overrides, "append")
+				}
+				`,
+				ConfigStateChecks: []statecheck.StateCheck{
+					statecheck.ExpectKnownOutputValue("test",
+						knownvalue.MapExact(map[string]knownvalue.Check{
+							"logging": knownvalue.MapExact(map[string]knownvalue.Check{
+								"level": knownvalue.StringExact("debug"),
+							}),
+							"resources": knownvalue.MapExact(map[string]knownvalue.Check{
+								"cpu":    knownvalue.StringExact("100m"),
+								"memory": knownvalue.StringExact("256Mi"),
+							}),
+							"hosts": knownvalue.ListExact([]knownvalue.Check{
+								knownvalue.StringExact("c"),
+							}),
+						}),
+					),
+				},
+			},
+			{
+				Config: `
+				locals {
+					base = {
+						containers = [
+							{ name = "app", image = "app:1" },
+							{ name = "debug", image = "busybox" },
+						]
+					}
+					overrides = {
+						containers = [
+							{ name = "debug", "$patch" = "delete" },
+							{ name = "app", image = "app:2" },
+						]
+					}
+				}
+				output "test" {
+					value = provider::deepmerge::mergo(local.base, local.overrides, "merge_lists_by:name")
+				}
+				`,
+				ConfigStateChecks: []statecheck.StateCheck{
+					statecheck.ExpectKnownOutputValue("test",
+						knownvalue.MapExact(map[string]knownvalue.Check{
+							"containers": knownvalue.ListExact([]knownvalue.Check{
+								knownvalue.MapExact(map[string]knownvalue.Check{
+									"name":  knownvalue.StringExact("app"),
+									"image": knownvalue.StringExact("app:2"),
+								}),
+							}),
+						}),
+					),
+				},
+			},
+			{
+				Config: `
+				output "test" {
+					value = provider::deepmerge::mergo({}, { a = { "$patch" = "remove" } })
+				}
+				`,
+				ExpectError: regexp.MustCompile(`\$patch at a must be one of`),
+			},
+		},
+	})
+}
+
+func TestMergoFunction_TypeConflicts(t *testing.T) {
+	resource.UnitTest(t, resource.TestCase{
+		TerraformVersionChecks: []tfversion.TerraformVersionCheck{
+			tfversion.SkipBelow(tfversion.Version1_8_0),
+		},
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: `
+				locals {
+					base     = { a = { b = { c = 1 } }, s = "x" }
+					override = { a = { b = "oops" } }
+				}
+				output "test" {
+					value = provider::deepmerge::mergo(local.base, local.override, "strict_types")
+				}
+				`,
+				ExpectError: regexp.MustCompile(`(?s)argument 2.*type conflict at a\.b: cannot merge string into map`),
+			},
+			{
+				Config: `
+				locals {
+					base     = { services = tomap({ api = { ports = [80, 443, { name = "admin" }] } }) }
+					override = { services = { api = { ports = [80, 443, "8443"] } } }
+				}
+				output "test" {
+					value = provider::deepmerge::mergo(local.base, local.override, "zip_lists", "strict_types")
+				}
+				`,
+				ExpectError: regexp.MustCompile(`type conflict at services\["api"\]\.ports\[2\]: cannot merge string\s+into map`),
+			},
+			{
+				Config: `
+				locals {
+					base     = { a = { b = { c = 1 } }, s = "x" }
+					override = { a = { b = { d = 2 } }, s = "y" }
+				}
+				output "test" {
+					value = provider::deepmerge::mergo(local.base, local.override, "strict_types")
+				}
+				`,
+				ConfigStateChecks: []statecheck.StateCheck{
+					statecheck.ExpectKnownOutputValue("test",
+						knownvalue.MapExact(map[string]knownvalue.Check{
+							"a": knownvalue.MapExact(map[string]knownvalue.Check{
+								"b": knownvalue.MapExact(map[string]knownvalue.Check{
+									"c": knownvalue.Int64Exact(1),
+									"d": knownvalue.Int64Exact(2),
+								}),
+							}),
+							"s": knownvalue.StringExact("y"),
+						}),
+					),
+				},
+			},
+			{
+				Config: `
+				locals {
+					base     = { a = { b = { c = 1 } }, l = [1], s = "x" }
+					override = { a = { b = "oops" }, l = "z", s = { x = 1 } }
+				}
+				output "test" {
+					value = provider::deepmerge::mergo(local.base, local.override, "keep_types")
+				}
+				`,
+				ConfigStateChecks: []statecheck.StateCheck{
+					statecheck.ExpectKnownOutputValue("test",
+						knownvalue.MapExact(map[string]knownvalue.Check{
+							"a": knownvalue.MapExact(map[string]knownvalue.Check{
+								"b": knownvalue.MapExact(map[string]knownvalue.Check{
+									"c": knownvalue.Int64Exact(1),
+								}),
+							}),
+							"l": knownvalue.ListExact([]knownvalue.Check{
+								knownvalue.Int64Exact(1),
+							}),
+							"s": knownvalue.MapExact(map[string]knownvalue.Check{
+								"x": knownvalue.Int64Exact(1),
+							}),
+						}),
+					),
+				},
+			},
+		},
+	})
+}
+
+func TestMergoFunction_NoEmptyOverride(t *testing.T) {
+	resource.UnitTest(t, resource.TestCase{
+		TerraformVersionChecks: []tfversion.TerraformVersionCheck{
+			tfversion.SkipBelow(tfversion.Version1_8_0),
+		},
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: `
+				locals {
+					defaults = {
+						name = "app"
+						tags = ["base"]
+						settings = { region = "eu-west-1", replicas = 2 }
+					}
+					inputs = {
+						name = ""
+						tags = []
+						settings = { region = "", labels = {} }
+						owner = ""
+					}
+				}
+				output "test" {
+					value = provider::deepmerge::mergo(local.defaults, local.inputs, "no_empty_override")
+				}
+				`,
+				ConfigStateChecks: []statecheck.StateCheck{
+					statecheck.ExpectKnownOutputValue("test",
+						knownvalue.MapExact(map[string]knownvalue.Check{
+							"name": knownvalue.StringExact("app"),
+							"tags": knownvalue.ListExact([]knownvalue.Check{
+								knownvalue.StringExact("base"),
+							}),
+							"settings": knownvalue.MapExact(map[string]knownvalue.Check{
+								"region":   knownvalue.StringExact("eu-west-1"),
+								"replicas": knownvalue.Int64Exact(2),
+								"labels":   knownvalue.MapExact(map[string]knownvalue.Check{}),
+							}),
+							"owner": knownvalue.StringExact(""),
+						}),
+					),
+				},
+			},
+			{
+				Config: `
+				locals {
+					defaults = { name = "app", tags = ["base"] }
+					inputs   = { name = "", tags = ["extra"] }
+				}
+				output "test" {
+					value = provider::deepmerge::mergo(local.defaults, local.inputs, "no_empty_override", "append")
+				}
+				`,
+				ConfigStateChecks: []statecheck.StateCheck{
+					statecheck.ExpectKnownOutputValue("test",
+						knownvalue.MapExact(map[string]knownvalue.Check{
+							"name": knownvalue.StringExact("app"),
+							"tags": knownvalue.ListExact([]knownvalue.Check{
+								knownvalue.StringExact("base"),
+								knownvalue.StringExact("extra"),
+							}),
+						}),
+					),
+				},
+			},
+		},
+	})
+}
+
+func TestMergoFunction_NoConflict(t *testing.T) {
+	resource.UnitTest(t, resource.TestCase{
+		TerraformVersionChecks: []tfversion.TerraformVersionCheck{
+			tfversion.SkipBelow(tfversion.Version1_8_0),
+		},
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: `
+				locals {
+					network = { vpc = { cidr = "10.0.0.0/16" } }
+					dns     = { zone = "example.com", vpc = { cidr = "10.0.0.0/16" } }
+				}
+				output "test" {
+					value = provider::deepmerge::mergo(local.network, local.dns, "no_conflict")
+				}
+				`,
+				ConfigStateChecks: []statecheck.StateCheck{
+					statecheck.ExpectKnownOutputValue("test",
+						knownvalue.MapExact(map[string]knownvalue.Check{
+							"vpc": knownvalue.MapExact(map[string]knownvalue.Check{
+								"cidr": knownvalue.StringExact("10.0.0.0/16"),
+							}),
+							"zone": knownvalue.StringExact("example.com"),
+						}),
+					),
+				},
+			},
+			{
+				Config: `
+				locals {
+					network = { vpc = { cidr = "10.0.0.0/16" } }
+					dns     = { zone = "example.com" }
+					other   = { vpc = { cidr = "10.1.0.0/16" } }
+				}
+				output "test" {
+					value = provider::deepmerge::mergo(local.network, local.dns, local.other, "strict")
+				}
+				`,
+				ExpectError: regexp.MustCompile(`conflicting values at vpc\.cidr from arguments 1 and 3`),
+			},
+			{
+				Config: `
+				locals {
+					a = { tags = ["a"] }
+					b = { tags = ["b"] }
+				}
+				output "test" {
+					value = provider::deepmerge::mergo(local.a, local.b, "append", "no_conflict")
+				}
+				`,
+				ConfigStateChecks: []statecheck.StateCheck{
+					statecheck.ExpectKnownOutputValue("test",
+						knownvalue.MapExact(map[string]knownvalue.Check{
+							"tags": knownvalue.ListExact([]knownvalue.Check{
+								knownvalue.StringExact("a"),
+								knownvalue.StringExact("b"),
+							}),
+						}),
+					),
+				},
+			},
+		},
+	})
+}
+
+func TestMergoFunction_OptionsObject(t *testing.T) {
+	resource.UnitTest(t, resource.TestCase{
+		TerraformVersionChecks: []tfversion.TerraformVersionCheck{
+			tfversion.SkipBelow(tfversion.Version1_8_0),
+		},
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: `
+				locals {
+					base      = { name = "app", tags = ["a"], settings = { level = "info" } }
+					overrides = { name = null, tags = ["b", "a"], settings = { level = "debug" } }
+				}
+				output "test" {
+					value = provider::deepmerge::mergo(local.base, local.overrides, {
+						"$options" = { lists = "union", nulls = "ignore", override = true }
+					})
+				}
+				`,
+				ConfigStateChecks: []statecheck.StateCheck{
+					statecheck.ExpectKnownOutputValue("test",
+						knownvalue.MapExact(map[string]knownvalue.Check{
+							"name": knownvalue.StringExact("app"),
+							"tags": knownvalue.ListExact([]knownvalue.Check{
+								knownvalue.StringExact("a"),
+								knownvalue.StringExact("b"),
+							}),
+							"settings": knownvalue.MapExact(map[string]knownvalue.Check{
+								"level": knownvalue.StringExact("debug"),
+							}),
+						}),
+					),
+				},
+			},
+			{
+				Config: `
+				locals {
+					base      = { settings = { level = "info", format = "json" } }
+					overrides = { settings = { level = "debug" } }
+				}
+				output "test" {
+					value = provider::deepmerge::mergo(local.base, local.overrides, { "$options" = { depth = 1 } })
+				}
+				`,
+				ConfigStateChecks: []statecheck.StateCheck{
+					statecheck.ExpectKnownOutputValue("test",
+						knownvalue.MapExact(map[string]knownvalue.Check{
+							"settings": knownvalue.MapExact(map[string]knownvalue.Check{
+								"level": knownvalue.StringExact("debug"),
+							}),
+						}),
+					),
+				},
+			},
+			{
+				Config: `
+				output "test" {
+					value = provider::deepmerge::mergo({}, { "$options" = { lists = "unoin" } })
+				}
+				`,
+				ExpectError: regexp.MustCompile(`\$options\.lists must be one of`),
+			},
+			{
+				Config: `
+				output "test" {
+					value = provider::deepmerge::mergo({}, { "$options" = { list = "union" } })
+				}
+				`,
+				ExpectError: regexp.MustCompile(`\$options: unsupported field "list"`),
+			},
+		},
+	})
+}
+
+func TestMergoFunction_Sequential(t *testing.T) {
+	resource.UnitTest(t, resource.TestCase{
+		TerraformVersionChecks: []tfversion.TerraformVersionCheck{
+			tfversion.SkipBelow(tfversion.Version1_8_0),
+		},
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: `
+				locals {
+					defaults = { region = "eu-west-1", size = "small" }
+					org      = { region = "us-east-1", owner = "platform" }
+					env      = { size = "large" }
+				}
+				output "test" {
+					value = provider::deepmerge::mergo("sequential", local.defaults, "no_override", local.org, "override", local.env)
+				}
+				`,
+				ConfigStateChecks: []statecheck.StateCheck{
+					statecheck.ExpectKnownOutputValue("test",
+						knownvalue.MapExact(map[string]knownvalue.Check{
+							"region": knownvalue.StringExact("eu-west-1"),
+							"size":   knownvalue.StringExact("large"),
+							"owner":  knownvalue.StringExact("platform"),
+						}),
+					),
+				},
+			},
+			{
+				Config: `
+				locals {
+					defaults = { region = "eu-west-1", size = "small" }
+					org      = { region = "us-east-1", owner = "platform" }
+					env      = { size = "large" }
+				}
+				output "test" {
+					value = provider::deepmerge::mergo(local.defaults, "no_override", local.org, "override", local.env)
+				}
+				`,
+				ConfigStateChecks: []statecheck.StateCheck{
+					statecheck.ExpectKnownOutputValue("test",
+						knownvalue.MapExact(map[string]knownvalue.Check{
+							"region": knownvalue.StringExact("us-east-1"),
+							"size":   knownvalue.StringExact("large"),
+							"owner":  knownvalue.StringExact("platform"),
+						}),
+					),
+				},
+			},
+			{
+				Config: `
+				output "test" {
+					value = provider::deepmerge::mergo("sequential", { a = 1 }, { a = 2 }, "no_override")
+				}
+				`,
+				ExpectError: regexp.MustCompile(`option follows the last map`),
+			},
+		},
+	})
+}
+
+func TestMergoFunction_ListOfMaps(t *testing.T) {
+	resource.UnitTest(t, resource.TestCase{
+		TerraformVersionChecks: []tfversion.TerraformVersionCheck{
+			tfversion.SkipBelow(tfversion.Version1_8_0),
+		},
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: `
+				locals {
+					layers = [
+						{ a = 1, tags = ["x"] },
+						null,
+						{ b = 2, tags = ["y"] },
+					]
+				}
+				output "test" {
+					value = provider::deepmerge::mergo(local.layers, { a = 3 }, "append")
+				}
+				`,
+				ConfigStateChecks: []statecheck.StateCheck{
+					statecheck.ExpectKnownOutputValue("test",
+						knownvalue.MapExact(map[string]knownvalue.Check{
+							"a": knownvalue.Int64Exact(3),
+							"b": knownvalue.Int64Exact(2),
+							"tags": knownvalue.ListExact([]knownvalue.Check{
+								knownvalue.StringExact("x"),
+								knownvalue.StringExact("y"),
+							}),
+						}),
+					),
+				},
+			},
+			{
+				Config: `
+				output "test" {
+					value = provider::deepmerge::mergo([for n in ["a", "b"] : { (n) = n }])
+				}
+				`,
+				ConfigStateChecks: []statecheck.StateCheck{
+					statecheck.ExpectKnownOutputValue("test",
+						knownvalue.MapExact(map[string]knownvalue.Check{
+							"a": knownvalue.StringExact("a"),
+							"b": knownvalue.StringExact("b"),
+						}),
+					),
+				},
+			},
+			{
+				Config: `
+				output "test" {
+					value = provider::deepmerge::mergo([{ a = 1 }, "append"])
+				}
+				`,
+				ExpectError: regexp.MustCompile(`unsupported tuple argument: element 1 is a string, not a map`),
+			},
+		},
+	})
+}
+
+func TestMergoFunction_CollectionTypes(t *testing.T) {
+	resource.UnitTest(t, resource.TestCase{
+		TerraformVersionChecks: []tfversion.TerraformVersionCheck{
+			tfversion.SkipBelow(tfversion.Version1_8_0),
+		},
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				// values compare equal only if their types match
+				Config: `
+				locals {
+					merged = provider::deepmerge::mergo(
+						{ tags = tomap({ a = "1" }), zones = toset(["a", "b"]), ports = tolist([80]) },
+						{ tags = tomap({ b = "2" }), zones = toset(["b", "c"]), ports = tolist([443]) },
+						"union",
+					)
+				}
+				output "test" {
+					value = [
+						local.merged.tags == tomap({ a = "1", b = "2" }),
+						local.merged.zones == toset(["a", "b", "c"]),
+						local.merged.ports == tolist([80, 443]),
+						provider::deepmerge::mergo(tomap({ a = "1" }), tomap({ b = "2" })) == tomap({ a = "1", b = "2" }),
+					]
+				}
+				`,
+				ConfigStateChecks: []statecheck.StateCheck{
+					statecheck.ExpectKnownOutputValue("test",
+						knownvalue.ListExact([]knownvalue.Check{
+							knownvalue.Bool(true),
+							knownvalue.Bool(true),
+							knownvalue.Bool(true),
+							knownvalue.Bool(true),
+						}),
+					),
+				},
+			},
+			{
+				// layers that disagree on the type produce an object or tuple
+				Config: `
+				locals {
+					merged = provider::deepmerge::mergo(
+						{ tags = tomap({ a = "1" }), ports = tolist([80]) },
+						{ tags = { b = "2" }, ports = [443] },
+						"append",
+					)
+				}
+				output "test" {
+					value = [
+						local.merged.tags == { a = "1", b = "2" },
+						local.merged.ports == [80, 443],
+						provider::deepmerge::mergo(tomap({ a = "1" }), { b = 2 }) == { a = "1", b = 2 },
+					]
+				}
+				`,
+				ConfigStateChecks: []statecheck.StateCheck{
+					statecheck.ExpectKnownOutputValue("test",
+						knownvalue.ListExact([]knownvalue.Check{
+							knownvalue.Bool(true),
+							knownvalue.Bool(true),
+							knownvalue.Bool(true),
+						}),
+					),
+				},
+			},
+			{
+				// empty collections keep their element type
+				Config: `
+				variable "tags" {
+					type    = map(string)
+					default = {}
+				}
+				variable "ports" {
+					type    = list(number)
+					default = []
+				}
+				locals {
+					merged = provider::deepmerge::mergo(
+						{ tags = var.tags, ports = var.ports },
+						{ tags = var.tags, ports = var.ports, name = "x" },
+						"append",
+					)
+				}
+				output "test" {
+					value = [
+						local.merged.tags == var.tags,
+						local.merged.ports == var.ports,
+					]
+				}
+				`,
+				ConfigStateChecks: []statecheck.StateCheck{
+					statecheck.ExpectKnownOutputValue("test",
+						knownvalue.ListExact([]knownvalue.Check{
+							knownvalue.Bool(true),
+							knownvalue.Bool(true),
+						}),
+					),
+				},
+			},
+		},
+	})
+}
+
+func TestMergoFunction_Numbers(t *testing.T) {
+	accountID, _, _ := big.ParseFloat("123456789012345678", 10, 512, big.ToNearestEven)
+
+	resource.UnitTest(t, resource.TestCase{
+		TerraformVersionChecks: []tfversion.TerraformVersionCheck{
+			tfversion.SkipBelow(tfversion.Version1_8_0),
+		},
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				// too large to survive conversion to float64
+				Config: `
+				output "test" {
+					value = provider::deepmerge::mergo({ account = 123456789012345678 }, { region = "eu-west-1" })
+				}
+				`,
+				ConfigStateChecks: []statecheck.StateCheck{
+					statecheck.ExpectKnownOutputValue("test",
+						knownvalue.MapExact(map[string]knownvalue.Check{
+							"account": knownvalue.NumberExact(accountID),
+							"region":  knownvalue.StringExact("eu-west-1"),
+						}),
+					),
+				},
+			},
+			{
+				// numerically equal values are the same, however written
+				Config: `
+				output "test" {
+					value = provider::deepmerge::mergo({ ports = [1, 2] }, { ports = [1.0, 2.50, 3] }, "union")
+				}
+				`,
+				ConfigStateChecks: []statecheck.StateCheck{
+					statecheck.ExpectKnownOutputValue("test",
+						knownvalue.MapExact(map[string]knownvalue.Check{
+							"ports": knownvalue.ListExact([]knownvalue.Check{
+								knownvalue.Int64Exact(1),
+								knownvalue.Int64Exact(2),
+								knownvalue.Float64Exact(2.5),
+								knownvalue.Int64Exact(3),
+							}),
+						}),
+					),
+				},
+			},
+			{
+				Config: `
+				output "test" {
+					value = provider::deepmerge::mergo(
+						{ users = [{ id = 1, name = "a" }] },
+						{ users = [{ id = 1.0, admin = true }] },
+						"merge_lists_by:id",
+					)
+				}
+				`,
+				ConfigStateChecks: []statecheck.StateCheck{
+					statecheck.ExpectKnownOutputValue("test",
+						knownvalue.MapExact(map[string]knownvalue.Check{
+							"users": knownvalue.ListExact([]knownvalue.Check{
+								knownvalue.MapExact(map[string]knownvalue.Check{
+									"id":    knownvalue.Int64Exact(1),
+									"name":  knownvalue.StringExact("a"),
+									"admin": knownvalue.Bool(true),
+								}),
+							}),
+						}),
+					),
+				},
+			},
+		},
+	})
+}
+
+func TestMergoFunction_TypedNulls(t *testing.T) {
+	resource.UnitTest(t, resource.TestCase{
+		TerraformVersionChecks: []tfversion.TerraformVersionCheck{
+			tfversion.SkipBelow(tfversion.Version1_8_0),
+		},
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				// values compare equal only if their types match
+				Config: `
+				variable "override" {
+					type    = object({ name = string, tags = map(string) })
+					default = { name = null, tags = null }
+				}
+				locals {
+					merged = provider::deepmerge::mergo({ name = "a", tags = { env = "dev" } }, var.override)
+				}
+				output "test" {
+					value = [
+						local.merged == var.override,
+						provider::deepmerge::mergo(tomap({ a = "1" }), tomap({ b = tostring(null) })) == tomap({ a = "1", b = null }),
+					]
+				}
+				`,
+				ConfigStateChecks: []statecheck.StateCheck{
+					statecheck.ExpectKnownOutputValue("test",
+						knownvalue.ListExact([]knownvalue.Check{
+							knownvalue.Bool(true),
+							knownvalue.Bool(true),
+						}),
+					),
+				},
+			},
+		},
+	})
+}
+
+func TestMergoFunction_TypeConstraint(t *testing.T) {
+	resource.UnitTest(t, resource.TestCase{
+		TerraformVersionChecks: []tfversion.TerraformVersionCheck{
+			tfversion.SkipBelow(tfversion.Version1_8_0),
+		},
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: `
+				locals {
+					services = provider::deepmerge::mergo(
+						{ web = { image = "nginx", port = "8080" } },
+						{ db = { image = "postgres" } },
+						"type:map(object({ image = string, port = optional(number, 80) }))",
+					)
+				}
+				output "test" {
+					value = [
+						local.services,
+						local.services == tomap({
+							web = { image = "nginx", port = 8080 },
+							db  = { image = "postgres", port = 80 },
+						}),
+					]
+				}
+				`,
+				ConfigStateChecks: []statecheck.StateCheck{
+					statecheck.ExpectKnownOutputValue("test",
+						knownvalue.ListExact([]knownvalue.Check{
+							knownvalue.MapExact(map[string]knownvalue.Check{
+								"web": knownvalue.MapExact(map[string]knownvalue.Check{
+									"image": knownvalue.StringExact("nginx"),
+									"port":  knownvalue.Int64Exact(8080),
+								}),
+								"db": knownvalue.MapExact(map[string]knownvalue.Check{
+									"image": knownvalue.StringExact("postgres"),
+									"port":  knownvalue.Int64Exact(80),
+								}),
+							}),
+							knownvalue.Bool(true),
+						}),
+					),
+				},
+			},
+			{
+				Config: `
+				output "test" {
+					value = provider::deepmerge::mergo(
+						{ web = { image = "nginx", port = 80 } },
+						{ web = { port = "http" } },
+						"type:map(object({ image = string, port = number }))",
+					)
+				}
+				`,
+				ExpectError: regexp.MustCompile(`result does not match type .* at \["web"\]\.port: a number is\s+required`),
+			},
+			{
+				Config: `
+				output "test" {
+					value = provider::deepmerge::mergo({ a = 1 }, "type:map(strng)")
+				}
+				`,
+				ExpectError: regexp.MustCompile(`invalid type constraint "map\(strng\)"`),
+			},
+		},
+	})
+}
+
+func TestMergoFunction_NormalizeLists(t *testing.T) {
+	resource.UnitTest(t, resource.TestCase{
+		TerraformVersionChecks: []tfversion.TerraformVersionCheck{
+			tfversion.SkipBelow(tfversion.Version1_8_0),
+		},
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: `
+				locals {
+					merged = provider::deepmerge::mergo(
+						{ rules = [{ name = "http", port = 80 }] },
+						{ rules = [{ name = "ssh", cidrs = ["10.0.0.0/8"] }] },
+						"append",
+						"normalize_lists",
+					)
+				}
+				output "test" {
+					value = [for rule in local.merged.rules : [rule.name, rule.port, rule.cidrs]]
+				}
+				`,
+				ConfigStateChecks: []statecheck.StateCheck{
+					statecheck.ExpectKnownOutputValue("test",
+						knownvalue.ListExact([]knownvalue.Check{
+							knownvalue.ListExact([]knownvalue.Check{
+								knownvalue.StringExact("http"),
+								knownvalue.Int64Exact(80),
+								knownvalue.Null(),
+							}),
+							knownvalue.ListExact([]knownvalue.Check{
+								knownvalue.StringExact("ssh"),
+								knownvalue.Null(),
+								knownvalue.ListExact([]knownvalue.Check{
+									knownvalue.StringExact("10.0.0.0/8"),
+								}),
+							}),
+						}),
+					),
+				},
+			},
+			{
+				// the normalized rules convert to list(object)
+				Config: `
+				locals {
+					merged = provider::deepmerge::mergo(
+						{ rules = [{ name = "http", port = 80 }] },
+						{ rules = [{ name = "ssh", cidrs = ["10.0.0.0/8"] }] },
+						"append",
+						"normalize_lists",
+						"type:object({ rules = list(object({ name = string, port = number, cidrs = list(string) })) })",
+					)
+				}
+				output "test" {
+					value = length(local.merged.rules)
+				}
+				`,
+				ConfigStateChecks: []statecheck.StateCheck{
+					statecheck.ExpectKnownOutputValue("test", knownvalue.Int64Exact(2)),
+				},
+			},
+		},
+	})
+}
@@ -4,12 +4,15 @@
 package provider
 
 import (
+	"regexp"
 	"testing"
 
 	"github.com/hashicorp/go-version"
 	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
 	"github.com/hashicorp/terraform-plugin-testing/knownvalue"
+	"github.com/hashicorp/terraform-plugin-testing/plancheck"
 	"github.com/hashicorp/terraform-plugin-testing/statecheck"
+	"github.com/hashicorp/terraform-plugin-testing/tfjsonpath"
 	"github.com/hashicorp/terraform-plugin-testing/tfversion"
 )
 
@@ -134,6 +137,36 @@ func TestMergoFunction_NestedUnknown(t *testing.T) {
 					),
 				},
 			},
+			// Test: An unknown object merged into a map that earlier maps have already merged into is unknown
+			{
+				Config: `
+				resource "random_string" "test" {
+					length = 8
+				}
+				locals {
+					map1 = { m = { a = "1" } }
+					map2 = { m = { b = "2" } }
+					map3 = { m = random_string.test.result == "" ? { c = "x" } : { c = "y" } }
+				}
+				output "test" {
+					value = provider::deepmerge::mergo(local.map1, local.map2, local.map3)
+				}
+				`,
+				ConfigPlanChecks: resource.ConfigPlanChecks{
+					PreApply: []plancheck.PlanCheck{
+						plancheck.ExpectUnknownOutputValueAtPath("test", tfjsonpath.New("m")),
+					},
+				},
+				ConfigStateChecks: []statecheck.StateCheck{
+					statecheck.ExpectKnownOutputValue("test", knownvalue.ObjectExact(map[string]knownvalue.Check{
+						"m": knownvalue.ObjectExact(map[string]knownvalue.Check{
+							"a": knownvalue.StringExact("1"),
+							"b": knownvalue.StringExact("2"),
+							"c": knownvalue.StringExact("y"),
+						}),
+					})),
+				},
+			},
 		},
 	})
 }
@@ -179,6 +212,28 @@ func TestMergoFunction_UnknownWithOptions(t *testing.T) {
 					),
 				},
 			},
+			// Test: An unknown option after the last map in sequential mode makes the result unknown
+			{
+				Config: `
+				resource "random_string" "test" {
+					length = 8
+				}
+				output "test" {
+					value = provider::deepmerge::mergo("sequential", { a = "1" }, { b = "2" }, random_string.test.result == "" ? "normalize" : "sequential")
+				}
+				`,
+				ConfigPlanChecks: resource.ConfigPlanChecks{
+					PreApply: []plancheck.PlanCheck{
+						plancheck.ExpectUnknownOutputValue("test"),
+					},
+				},
+				ConfigStateChecks: []statecheck.StateCheck{
+					statecheck.ExpectKnownOutputValue("test", knownvalue.ObjectExact(map[string]knownvalue.Check{
+						"a": knownvalue.StringExact("1"),
+						"b": knownvalue.StringExact("2"),
+					})),
+				},
+			},
 		},
 	})
 }
@@ -230,3 +285,469 @@ func TestMergoFunction_MultipleUnknowns(t *testing.T) {
 		},
 	})
 }
+
+func TestMergoFunction_MergeListsByUnknownKey(t *testing.T) {
+	resource.UnitTest(t, resource.TestCase{
+		TerraformVersionChecks: []tfversion.TerraformVersionCheck{
+			tfversion.SkipBelow(version.Must(version.NewVersion("1.8.0"))),
+		},
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		ExternalProviders: map[string]resource.ExternalProvider{
+			"random": {
+				Source: "hashicorp/random",
+			},
+		},
+		Steps: []resource.TestStep{
+			// Test: An unknown key value makes the keyed list unknown, siblings stay known
+			{
+				Config: `
+				resource "random_string" "test" {
+					length = 8
+				}
+				locals {
+					map1 = {
+						a     = "known"
+						items = [{ name = "x", v = 1 }]
+					}
+					map2 = {
+						items = [{ name = random_string.test.result, v = 2 }]
+					}
+				}
+				output "test" {
+					value = provider::deepmerge::mergo(local.map1, local.map2, "merge_lists_by:name")
+				}
+				`,
+				ConfigStateChecks: []statecheck.StateCheck{
+					statecheck.ExpectKnownOutputValue("test",
+						knownvalue.MapPartial(map[string]knownvalue.Check{
+							"a": knownvalue.StringExact("known"),
+						}),
+					),
+				},
+			},
+		},
+	})
+}
+
+func TestMergoFunction_UnknownPerMode(t *testing.T) {
+	resource.UnitTest(t, resource.TestCase{
+		TerraformVersionChecks: []tfversion.TerraformVersionCheck{
+			tfversion.SkipBelow(version.Must(version.NewVersion("1.8.0"))),
+		},
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		ExternalProviders: map[string]resource.ExternalProvider{
+			"random": {
+				Source: "hashicorp/random",
+			},
+		},
+		Steps: []resource.TestStep{
+			// Test: With no_override, a known earlier value wins over a later unknown and stays known during plan
+			{
+				Config: `
+				resource "random_string" "test" {
+					length = 8
+				}
+				locals {
+					map1 = { a = "base" }
+					map2 = { a = random_string.test.result, b = random_string.test.result }
+				}
+				output "test" {
+					value = provider::deepmerge::mergo(local.map1, local.map2, "no_override")
+				}
+				`,
+				ConfigPlanChecks: resource.ConfigPlanChecks{
+					PreApply: []plancheck.PlanCheck{
+						plancheck.ExpectKnownOutputValueAtPath("test", tfjsonpath.New("a"), knownvalue.StringExact("base")),
+						plancheck.ExpectUnknownOutputValueAtPath("test", tfjsonpath.New("b")),
+					},
+				},
+				ConfigStateChecks: []statecheck.StateCheck{
+					statecheck.ExpectKnownOutputValueAtPath("test", tfjsonpath.New("a"), knownvalue.StringExact("base")),
+				},
+			},
+			// Test: With no_override, a later unknown may fill in an earlier zero value, so is unknown during plan
+			{
+				Config: `
+				resource "random_string" "test" {
+					length = 8
+				}
+				locals {
+					map1 = { a = "" }
+					map2 = { a = random_string.test.result }
+				}
+				output "test" {
+					value = provider::deepmerge::mergo(local.map1, local.map2, "no_override")
+				}
+				`,
+				ConfigPlanChecks: resource.ConfigPlanChecks{
+					PreApply: []plancheck.PlanCheck{
+						plancheck.ExpectUnknownOutputValueAtPath("test", tfjsonpath.New("a")),
+					},
+				},
+				ConfigStateChecks: []statecheck.StateCheck{
+					statecheck.ExpectKnownOutputValueAtPath("test", tfjsonpath.New("a"), knownvalue.StringRegexp(regexp.MustCompile(`^.{8}$`))),
+				},
+			},
+			// Test: By default, a known later value replaces an earlier unknown and is known during plan
+			{
+				Config: `
+				resource "random_string" "test" {
+					length = 8
+				}
+				locals {
+					map1 = { a = random_string.test.result }
+					map2 = { a = "final" }
+				}
+				output "test" {
+					value = provider::deepmerge::mergo(local.map1, local.map2)
+				}
+				`,
+				ConfigPlanChecks: resource.ConfigPlanChecks{
+					PreApply: []plancheck.PlanCheck{
+						plancheck.ExpectKnownOutputValue("test", knownvalue.ObjectExact(map[string]knownvalue.Check{
+							"a": knownvalue.StringExact("final"),
+						})),
+					},
+				},
+			},
+			// Test: With no_empty_override, an empty value over an unknown depends on whether the unknown is null
+			{
+				Config: `
+				resource "random_string" "test" {
+					length = 8
+				}
+				locals {
+					map1 = { a = random_string.test.result }
+					map2 = { a = "" }
+				}
+				output "test" {
+					value = provider::deepmerge::mergo(local.map1, local.map2, "no_empty_override")
+				}
+				`,
+				ConfigPlanChecks: resource.ConfigPlanChecks{
+					PreApply: []plancheck.PlanCheck{
+						plancheck.ExpectUnknownOutputValueAtPath("test", tfjsonpath.New("a")),
+					},
+				},
+				ConfigStateChecks: []statecheck.StateCheck{
+					statecheck.ExpectKnownOutputValueAtPath("test", tfjsonpath.New("a"), knownvalue.StringRegexp(regexp.MustCompile(`^.{8}$`))),
+				},
+			},
+		},
+	})
+}
+
+func TestMergoFunction_UnknownListElements(t *testing.T) {
+	resource.UnitTest(t, resource.TestCase{
+		TerraformVersionChecks: []tfversion.TerraformVersionCheck{
+			tfversion.SkipBelow(version.Must(version.NewVersion("1.8.0"))),
+		},
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		ExternalProviders: map[string]resource.ExternalProvider{
+			"random": {
+				Source: "hashicorp/random",
+			},
+		},
+		Steps: []resource.TestStep{
+			// Test: Appending an unknown element keeps the known elements known during plan
+			{
+				Config: `
+				resource "random_string" "test" {
+					length = 8
+				}
+				locals {
+					map1 = { l = ["a"] }
+					map2 = { l = [random_string.test.result] }
+				}
+				output "test" {
+					value = provider::deepmerge::mergo(local.map1, local.map2, "append")
+				}
+				`,
+				ConfigPlanChecks: resource.ConfigPlanChecks{
+					PreApply: []plancheck.PlanCheck{
+						plancheck.ExpectKnownOutputValueAtPath("test", tfjsonpath.New("l").AtSliceIndex(0), knownvalue.StringExact("a")),
+						plancheck.ExpectUnknownOutputValueAtPath("test", tfjsonpath.New("l").AtSliceIndex(1)),
+					},
+				},
+				ConfigStateChecks: []statecheck.StateCheck{
+					statecheck.ExpectKnownOutputValueAtPath("test", tfjsonpath.New("l"), knownvalue.ListSizeExact(2)),
+				},
+			},
+			// Test: Appending a wholly unknown list makes the list unknown, siblings stay known
+			{
+				Config: `
+				resource "random_string" "test" {
+					length = 8
+				}
+				locals {
+					map1 = { a = "known", l = ["a"] }
+					map2 = { l = split(",", random_string.test.result) }
+				}
+				output "test" {
+					value = provider::deepmerge::mergo(local.map1, local.map2, "append")
+				}
+				`,
+				ConfigPlanChecks: resource.ConfigPlanChecks{
+					PreApply: []plancheck.PlanCheck{
+						plancheck.ExpectKnownOutputValueAtPath("test", tfjsonpath.New("a"), knownvalue.StringExact("known")),
+						plancheck.ExpectUnknownOutputValueAtPath("test", tfjsonpath.New("l")),
+					},
+				},
+				ConfigStateChecks: []statecheck.StateCheck{
+					statecheck.ExpectKnownOutputValueAtPath("test", tfjsonpath.New("l"), knownvalue.ListSizeExact(2)),
+				},
+			},
+			// Test: A wholly unknown list appended to a list that earlier maps have already appended to is unknown
+			{
+				Config: `
+				resource "random_string" "test" {
+					length = 8
+				}
+				locals {
+					map1 = { l = ["a"] }
+					map2 = { l = ["b"] }
+					map3 = { l = split(",", random_string.test.result) }
+				}
+				output "test" {
+					value = provider::deepmerge::mergo(local.map1, local.map2, local.map3, "append")
+				}
+				`,
+				ConfigPlanChecks: resource.ConfigPlanChecks{
+					PreApply: []plancheck.PlanCheck{
+						plancheck.ExpectUnknownOutputValueAtPath("test", tfjsonpath.New("l")),
+					},
+				},
+				ConfigStateChecks: []statecheck.StateCheck{
+					statecheck.ExpectKnownOutputValueAtPath("test", tfjsonpath.New("l"), knownvalue.ListSizeExact(3)),
+				},
+			},
+			// Test: A union with an element that may turn out to be a duplicate is unknown, as its length is
+			{
+				Config: `
+				resource "random_string" "test" {
+					length = 8
+				}
+				locals {
+					map1 = { a = "known", l = ["a"] }
+					map2 = { l = [random_string.test.result] }
+				}
+				output "test" {
+					value = provider::deepmerge::mergo(local.map1, local.map2, "union")
+				}
+				`,
+				ConfigPlanChecks: resource.ConfigPlanChecks{
+					PreApply: []plancheck.PlanCheck{
+						plancheck.ExpectKnownOutputValueAtPath("test", tfjsonpath.New("a"), knownvalue.StringExact("known")),
+						plancheck.ExpectUnknownOutputValueAtPath("test", tfjsonpath.New("l")),
+					},
+				},
+				ConfigStateChecks: []statecheck.StateCheck{
+					statecheck.ExpectKnownOutputValueAtPath("test", tfjsonpath.New("l"), knownvalue.ListSizeExact(2)),
+				},
+			},
+			// Test: A union with an unknown element that can't be a duplicate keeps its elements known
+			{
+				Config: `
+				resource "random_string" "test" {
+					length = 8
+				}
+				locals {
+					map1 = { l = ["a", "a"] }
+					map2 = { l = [{ name = random_string.test.result }] }
+				}
+				output "test" {
+					value = provider::deepmerge::mergo(local.map1, local.map2, "union")
+				}
+				`,
+				ConfigPlanChecks: resource.ConfigPlanChecks{
+					PreApply: []plancheck.PlanCheck{
+						plancheck.ExpectKnownOutputValueAtPath("test", tfjsonpath.New("l").AtSliceIndex(0), knownvalue.StringExact("a")),
+						plancheck.ExpectUnknownOutputValueAtPath("test", tfjsonpath.New("l").AtSliceIndex(1).AtMapKey("name")),
+					},
+				},
+				ConfigStateChecks: []statecheck.StateCheck{
+					statecheck.ExpectKnownOutputValueAtPath("test", tfjsonpath.New("l"), knownvalue.ListSizeExact(2)),
+				},
+			},
+			// Test: Zipped lists are merged element by element around an unknown
+			{
+				Config: `
+				resource "random_string" "test" {
+					length = 8
+				}
+				locals {
+					map1 = { l = [{ x = "a" }, { x = "b" }] }
+					map2 = { l = [{ y = random_string.test.result }, { y = "c" }] }
+				}
+				output "test" {
+					value = provider::deepmerge::mergo(local.map1, local.map2, "zip_lists")
+				}
+				`,
+				ConfigPlanChecks: resource.ConfigPlanChecks{
+					PreApply: []plancheck.PlanCheck{
+						plancheck.ExpectKnownOutputValueAtPath("test", tfjsonpath.New("l").AtSliceIndex(0).AtMapKey("x"), knownvalue.StringExact("a")),
+						plancheck.ExpectUnknownOutputValueAtPath("test", tfjsonpath.New("l").AtSliceIndex(0).AtMapKey("y")),
+						plancheck.ExpectKnownOutputValueAtPath("test", tfjsonpath.New("l").AtSliceIndex(1), knownvalue.ObjectExact(map[string]knownvalue.Check{
+							"x": knownvalue.StringExact("b"),
+							"y": knownvalue.StringExact("c"),
+						})),
+					},
+				},
+			},
+		},
+	})
+}
+
+func TestMergoFunction_UnknownObjectArgument(t *testing.T) {
+	resource.UnitTest(t, resource.TestCase{
+		TerraformVersionChecks: []tfversion.TerraformVersionCheck{
+			tfversion.SkipBelow(version.Must(version.NewVersion("1.8.0"))),
+		},
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		ExternalProviders: map[string]resource.ExternalProvider{
+			"random": {
+				Source: "hashicorp/random",
+			},
+		},
+		Steps: []resource.TestStep{
+			// Test: A later known map setting every attribute of a wholly unknown object gives a known result,
+			// so for_each over the merged keys works during plan
+			{
+				Config: `
+				resource "random_string" "test" {
+					length = 8
+				}
+				locals {
+					map1 = random_string.test.result == "" ? { a = "x", b = "y" } : { a = "p", b = "q" }
+					map2 = { a = "1", b = "2" }
+				}
+				resource "terraform_data" "each" {
+					for_each = provider::deepmerge::mergo(local.map1, local.map2)
+					input    = each.value
+				}
+				output "test" {
+					value = provider::deepmerge::mergo(local.map1, local.map2)
+				}
+				`,
+				ConfigPlanChecks: resource.ConfigPlanChecks{
+					PreApply: []plancheck.PlanCheck{
+						plancheck.ExpectKnownOutputValue("test", knownvalue.ObjectExact(map[string]knownvalue.Check{
+							"a": knownvalue.StringExact("1"),
+							"b": knownvalue.StringExact("2"),
+						})),
+					},
+				},
+			},
+			// Test: The attributes of a wholly unknown object are unknown, those only earlier maps set stay known
+			{
+				Config: `
+				resource "random_string" "test" {
+					length = 8
+				}
+				locals {
+					map1 = { a = "1", c = "3" }
+					map2 = random_string.test.result == "" ? { a = "x", b = "y" } : { a = "p", b = "q" }
+				}
+				resource "terraform_data" "each" {
+					for_each = provider::deepmerge::mergo(local.map1, local.map2)
+					input    = each.value
+				}
+				output "test" {
+					value = provider::deepmerge::mergo(local.map1, local.map2)
+				}
+				`,
+				ConfigPlanChecks: resource.ConfigPlanChecks{
+					PreApply: []plancheck.PlanCheck{
+						plancheck.ExpectUnknownOutputValueAtPath("test", tfjsonpath.New("a")),
+						plancheck.ExpectKnownOutputValueAtPath("test", tfjsonpath.New("c"), knownvalue.StringExact("3")),
+					},
+				},
+				ConfigStateChecks: []statecheck.StateCheck{
+					statecheck.ExpectKnownOutputValue("test", knownvalue.ObjectExact(map[string]knownvalue.Check{
+						"a": knownvalue.StringExact("p"),
+						"b": knownvalue.StringExact("q"),
+						"c": knownvalue.StringExact("3"),
+					})),
+				},
+			},
+			// Test: An attribute only the unknown object supplies may not be there at all, as the object may be null
+			{
+				Config: `
+				resource "random_string" "test" {
+					length = 8
+				}
+				locals {
+					map1 = random_string.test.result == "" ? { a = "x", b = "y" } : { a = "p", b = "q" }
+					map2 = { a = "1" }
+				}
+				output "test" {
+					value = provider::deepmerge::mergo(local.map1, local.map2)
+				}
+				`,
+				ConfigPlanChecks: resource.ConfigPlanChecks{
+					PreApply: []plancheck.PlanCheck{
+						plancheck.ExpectUnknownOutputValue("test"),
+					},
+				},
+				ConfigStateChecks: []statecheck.StateCheck{
+					statecheck.ExpectKnownOutputValue("test", knownvalue.ObjectExact(map[string]knownvalue.Check{
+						"a": knownvalue.StringExact("1"),
+						"b": knownvalue.StringExact("q"),
+					})),
+				},
+			},
+		},
+	})
+}
+
+func TestMergoFunction_UnknownValidation(t *testing.T) {
+	resource.UnitTest(t, resource.TestCase{
+		TerraformVersionChecks: []tfversion.TerraformVersionCheck{
+			tfversion.SkipBelow(version.Must(version.NewVersion("1.8.0"))),
+		},
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		ExternalProviders: map[string]resource.ExternalProvider{
+			"random": {
+				Source: "hashicorp/random",
+			},
+		},
+		Steps: []resource.TestStep{
+			// Test: A misspelt option after an unknown argument is still reported during plan
+			{
+				Config: `
+				resource "random_string" "test" {
+					length = 8
+				}
+				output "test" {
+					value = provider::deepmerge::mergo({ a = "known" }, random_string.test.result, "apend")
+				}
+				`,
+				ExpectError: regexp.MustCompile(`did\s+you\s+mean\s+"append"\?`),
+			},
+			// Test: So is an unknown argument of the wrong type
+			{
+				Config: `
+				resource "random_string" "test" {
+					length = 8
+				}
+				output "test" {
+					value = provider::deepmerge::mergo({ a = "known" }, { b = random_string.test.result }, random_string.test.result != "")
+				}
+				`,
+				ExpectError: regexp.MustCompile(`unsupported bool argument`),
+			},
+			// Test: So is an option following the last map in sequential mode
+			{
+				Config: `
+				resource "random_string" "test" {
+					length = 8
+				}
+				output "test" {
+					value = provider::deepmerge::mergo("sequential", random_string.test.result, { a = 1 }, "no_override")
+				}
+				`,
+				ExpectError: regexp.MustCompile(`option follows the last map`),
+			},
+		},
+	})
+}
@@ -0,0 +1,135 @@
+// Copyright (c) Robin Breathe and contributors
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	_ "embed"
+	"math/big"
+	"slices"
+	"strconv"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/function"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-framework/types/basetypes"
+)
+
+var (
+	_ function.Function = MergoProvenanceFunction{}
+)
+
+func NewMergoProvenanceFunction() function.Function {
+	return MergoProvenanceFunction{}
+}
+
+type MergoProvenanceFunction struct{}
+
+func (r MergoProvenanceFunction) Metadata(_ context.Context, req function.MetadataRequest, resp *function.MetadataResponse) {
+	resp.Name = "mergo_provenance"
+}
+
+//go:embed mergo_provenance_function.md
+var mergoProvenanceFunctionDescription string
+
+func (r MergoProvenanceFunction) Definition(_ context.Context, _ function.DefinitionRequest, resp *function.DefinitionResponse) {
+	resp.Definition = function.Definition{
+		Summary:             "Report which argument supplied each value of a mergo deepmerge",
+		MarkdownDescription: mergoProvenanceFunctionDescription,
+		VariadicParameter: function.DynamicParameter{
+			Name:                "maps",
+			MarkdownDescription: "Maps to merge",
+			AllowNullValue:      true,
+			AllowUnknownValues:  true,
+		},
+		Return: function.DynamicReturn{},
+	}
+}
+
+func (r MergoProvenanceFunction) Run(ctx context.Context, req function.RunRequest, resp *function.RunResponse) {
+	args := make([]types.Dynamic, 0)
+
+	if resp.Error = function.ConcatFuncErrors(req.Arguments.Get(ctx, &args)); resp.Error != nil {
+		return
+	}
+
+	merged, origins, err := mergeArguments(ctx, args, true)
+	if err != nil {
+		resp.Error = err
+		return
+	}
+
+	if merged.IsUnknown() || merged.IsUnderlyingValueUnknown() {
+		resp.Error = function.ConcatFuncErrors(resp.Result.Set(ctx, types.DynamicUnknown()))
+		return
+	}
+
+	provenance := types.DynamicValue(origins.describe(ctx, nil, merged.UnderlyingValue()))
+	resp.Error = function.ConcatFuncErrors(resp.Result.Set(ctx, &provenance))
+}
+
+// describe returns a value shaped like v, the merged value at path, with
+// each leaf replaced by the position of the argument that supplied it, or,
+// for a map within a list argument, a list of the argument's position and
+// the map's index within it.
+func (o *origins) describe(ctx context.Context, path []string, v attr.Value) attr.Value {
+	var (
+		attrs map[string]attr.Value
+		elems []attr.Value
+	)
+
+	switch vv := v.(type) {
+	case basetypes.DynamicValue:
+		if !vv.IsNull() && !vv.IsUnknown() {
+			return o.describe(ctx, path, vv.UnderlyingValue())
+		}
+	case basetypes.ObjectValue:
+		attrs = vv.Attributes()
+	case basetypes.MapValue:
+		attrs = vv.Elements()
+	case basetypes.TupleValue:
+		elems = vv.Elements()
+	case basetypes.ListValue:
+		elems = vv.Elements()
+	case basetypes.SetValue:
+		elems = vv.Elements()
+	}
+
+	switch {
+	case attrs != nil && !v.IsNull() && !v.IsUnknown():
+		attrTypes := make(map[string]attr.Type, len(attrs))
+		attrValues := make(map[string]attr.Value, len(attrs))
+		for key, value := range attrs {
+			attrValues[key] = o.describe(ctx, append(slices.Clip(path), key), value)
+			attrTypes[key] = attrValues[key].Type(ctx)
+		}
+		return types.ObjectValueMust(attrTypes, attrValues)
+
+	case elems != nil && !v.IsNull() && !v.IsUnknown():
+		elemTypes := make([]attr.Type, len(elems))
+		elemValues := make([]attr.Value, len(elems))
+		for i, elem := range elems {
+			elemValues[i] = o.describe(ctx, append(slices.Clip(path), strconv.Itoa(i)), elem)
+			elemTypes[i] = elemValues[i].Type(ctx)
+		}
+		return types.TupleValueMust(elemTypes, elemValues)
+
+	default:
+		layer, ok := o.get(path)
+		if !ok {
+			return types.NumberNull()
+		}
+		arg := o.arguments[layer]
+		if arg.Element < 0 {
+			return types.NumberValue(new(big.Float).SetInt64(arg.Position))
+		}
+		return types.TupleValueMust(
+			[]attr.Type{types.NumberType, types.NumberType},
+			[]attr.Value{
+				types.NumberValue(new(big.Float).SetInt64(arg.Position)),
+				types.NumberValue(new(big.Float).SetInt64(arg.Element)),
+			},
+		)
+	}
+}
@@ -0,0 +1,168 @@
+// Copyright (c) Robin Breathe and contributors
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/hashicorp/terraform-plugin-testing/knownvalue"
+	"github.com/hashicorp/terraform-plugin-testing/statecheck"
+	"github.com/hashicorp/terraform-plugin-testing/tfversion"
+)
+
+func TestMergoProvenanceFunction_Default(t *testing.T) {
+	resource.UnitTest(t, resource.TestCase{
+		TerraformVersionChecks: []tfversion.TerraformVersionCheck{
+			tfversion.SkipBelow(tfversion.Version1_8_0),
+		},
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: `
+				locals {
+					base    = { network = { cidr = "10.0.0.0/16", zones = ["a", "b"] }, tags = ["base"] }
+					team    = { dns = { zone = "example.com" }, tags = ["team"] }
+					account = { network = { cidr = "10.1.0.0/16" } }
+				}
+				output "test" {
+					value = provider::deepmerge::mergo_provenance(local.base, local.team, local.account)
+				}
+				`,
+				ConfigStateChecks: []statecheck.StateCheck{
+					statecheck.ExpectKnownOutputValue("test",
+						knownvalue.MapExact(map[string]knownvalue.Check{
+							"network": knownvalue.MapExact(map[string]knownvalue.Check{
+								"cidr": knownvalue.Int64Exact(2),
+								"zones": knownvalue.ListExact([]knownvalue.Check{
+									knownvalue.Int64Exact(0),
+									knownvalue.Int64Exact(0),
+								}),
+							}),
+							"dns": knownvalue.MapExact(map[string]knownvalue.Check{
+								"zone": knownvalue.Int64Exact(1),
+							}),
+							"tags": knownvalue.ListExact([]knownvalue.Check{
+								knownvalue.Int64Exact(1),
+							}),
+						}),
+					),
+				},
+			},
+		},
+	})
+}
+
+func TestMergoProvenanceFunction_Modes(t *testing.T) {
+	resource.UnitTest(t, resource.TestCase{
+		TerraformVersionChecks: []tfversion.TerraformVersionCheck{
+			tfversion.SkipBelow(tfversion.Version1_8_0),
+		},
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: `
+				locals {
+					base    = { tags = ["base"], name = "app" }
+					team    = { tags = ["team", "shared"], name = "team-app" }
+					account = { tags = ["shared", "account"] }
+				}
+				output "test" {
+					value = provider::deepmerge::mergo_provenance("union", local.base, local.team, local.account)
+				}
+				`,
+				ConfigStateChecks: []statecheck.StateCheck{
+					statecheck.ExpectKnownOutputValue("test",
+						knownvalue.MapExact(map[string]knownvalue.Check{
+							"name": knownvalue.Int64Exact(2),
+							"tags": knownvalue.ListExact([]knownvalue.Check{
+								knownvalue.Int64Exact(1),
+								knownvalue.Int64Exact(2),
+								knownvalue.Int64Exact(2),
+								knownvalue.Int64Exact(3),
+							}),
+						}),
+					),
+				},
+			},
+			{
+				Config: `
+				locals {
+					base = {
+						containers = [
+							{ name = "app", image = "app:1" },
+							{ name = "sidecar", image = "proxy:1" },
+						]
+					}
+					overrides = {
+						containers = [
+							{ name = "sidecar", image = "proxy:2" },
+						]
+					}
+				}
+				output "test" {
+					value = provider::deepmerge::mergo_provenance(local.base, local.overrides, "merge_lists_by:name")
+				}
+				`,
+				ConfigStateChecks: []statecheck.StateCheck{
+					statecheck.ExpectKnownOutputValue("test",
+						knownvalue.MapExact(map[string]knownvalue.Check{
+							"containers": knownvalue.ListExact([]knownvalue.Check{
+								knownvalue.MapExact(map[string]knownvalue.Check{
+									"name":  knownvalue.Int64Exact(0),
+									"image": knownvalue.Int64Exact(0),
+								}),
+								knownvalue.MapExact(map[string]knownvalue.Check{
+									"name":  knownvalue.Int64Exact(1),
+									"image": knownvalue.Int64Exact(1),
+								}),
+							}),
+						}),
+					),
+				},
+			},
+			{
+				Config: `
+				output "test" {
+					value = provider::deepmerge::mergo_provenance({ a = 1 }, { a = 2 }, "no_conflict")
+				}
+				`,
+				ExpectError: regexp.MustCompile(`conflicting values at a from arguments 1 and 2`),
+			},
+		},
+	})
+}
+
+func TestMergoProvenanceFunction_ListOfMaps(t *testing.T) {
+	resource.UnitTest(t, resource.TestCase{
+		TerraformVersionChecks: []tfversion.TerraformVersionCheck{
+			tfversion.SkipBelow(tfversion.Version1_8_0),
+		},
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: `
+				output "test" {
+					value = provider::deepmerge::mergo_provenance({ a = 1 }, [{ b = 2 }, { a = 3 }])
+				}
+				`,
+				ConfigStateChecks: []statecheck.StateCheck{
+					statecheck.ExpectKnownOutputValue("test",
+						knownvalue.MapExact(map[string]knownvalue.Check{
+							"a": knownvalue.ListExact([]knownvalue.Check{
+								knownvalue.Int64Exact(1),
+								knownvalue.Int64Exact(1),
+							}),
+							"b": knownvalue.ListExact([]knownvalue.Check{
+								knownvalue.Int64Exact(1),
+								knownvalue.Int64Exact(0),
+							}),
+						}),
+					),
+				},
+			},
+		},
+	})
+}
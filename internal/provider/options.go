@@ -0,0 +1,327 @@
+// Copyright (c) Robin Breathe and contributors
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"math/big"
+	"slices"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/types/basetypes"
+)
+
+// optionsKey is the single attribute of an argument that carries merge
+// options as an object rather than data to be merged.
+const optionsKey = "$options"
+
+// mergeOptions control how maps are merged. They are set by string
+// arguments, or by the fields of an $options object.
+type mergeOptions struct {
+	with_override       bool
+	with_null_override  bool
+	with_empty_override bool
+	with_append         bool
+	with_union          bool
+	with_zip            bool
+	with_prepend        bool
+	merge_lists_by      string
+	knockout_prefix     string
+	type_conflicts      string
+	no_conflict         bool
+	depth               int64
+}
+
+func defaultMergeOptions() mergeOptions {
+	return mergeOptions{
+		with_override:       true,
+		with_null_override:  true,
+		with_empty_override: true,
+	}
+}
+
+// setString applies a string option, reporting false if it is not recognised.
+func (o *mergeOptions) setString(option string) bool {
+	switch option {
+	case "no_override":
+		o.with_override = false
+
+	case "no_null_override":
+		o.with_null_override = false
+
+	case "no_empty_override":
+		o.with_empty_override = false
+
+	case "no_conflict", "strict":
+		o.no_conflict = true
+
+	case "override", "replace":
+		o.with_override = true
+
+	case "append", "append_lists":
+		o.with_append = true
+
+	case "union", "union_lists":
+		o.with_union = true
+
+	case "zip", "zip_lists":
+		o.with_zip = true
+
+	case "prepend", "prepend_lists":
+		o.with_prepend = true
+
+	case "prepend_union":
+		o.with_prepend = true
+		o.with_union = true
+
+	case "strict_types":
+		o.type_conflicts = "strict"
+
+	case "keep_types":
+		o.type_conflicts = "keep"
+
+	default:
+		if key, ok := strings.CutPrefix(option, "merge_lists_by:"); ok && key != "" {
+			o.merge_lists_by = key
+			return true
+		}
+		if prefix, ok := strings.CutPrefix(option, "knockout:"); ok && prefix != "" {
+			o.knockout_prefix = prefix
+			return true
+		}
+		return false
+	}
+
+	return true
+}
+
+// optionFields are the fields accepted in an $options object, with the values
+// each accepts. An empty list means any value of the field's type.
+var optionFields = map[string][]string{
+	"override":       nil,
+	"nulls":          {"override", "ignore"},
+	"empty":          {"override", "ignore"},
+	"lists":          {"replace", "append", "prepend", "union", "prepend_union", "zip"},
+	"merge_lists_by": nil,
+	"knockout":       nil,
+	"types":          {"replace", "strict", "keep"},
+	"conflicts":      {"allow", "error"},
+	"depth":          nil,
+}
+
+// setFields applies the fields of an $options object. It reports false if
+// any field is unknown, leaving the options incomplete, though the other
+// fields are still checked.
+func (o *mergeOptions) setFields(v attr.Value) (bool, error) {
+	if v.IsUnknown() {
+		return false, nil
+	}
+
+	var fields map[string]attr.Value
+
+	switch vv := v.(type) {
+	case basetypes.ObjectValue:
+		fields = vv.Attributes()
+	case basetypes.MapValue:
+		fields = vv.Elements()
+	default:
+		return false, fmt.Errorf("%s must be an object of option fields", optionsKey)
+	}
+
+	known := true
+	for _, name := range slices.Sorted(maps.Keys(fields)) {
+		value := fields[name]
+		allowed, ok := optionFields[name]
+		if !ok {
+			names := slices.Sorted(maps.Keys(optionFields))
+			if suggestion, ok := didYouMean(name, names); ok {
+				return false, fmt.Errorf("%s: unsupported field %q, did you mean %q?", optionsKey, name, suggestion)
+			}
+			return false, fmt.Errorf("%s: unsupported field %q, expected one of %s", optionsKey, name, quotedList(names))
+		}
+
+		if dv, ok := value.(basetypes.DynamicValue); ok && !dv.IsNull() && !dv.IsUnknown() {
+			value = dv.UnderlyingValue()
+		}
+		if value.IsUnknown() {
+			known = false
+			continue
+		}
+		if value.IsNull() {
+			continue
+		}
+
+		if err := o.setField(name, value, allowed); err != nil {
+			return false, fmt.Errorf("%s.%s %w", optionsKey, name, err)
+		}
+	}
+
+	return known, nil
+}
+
+// setField applies a single, known and non-null, $options field.
+func (o *mergeOptions) setField(name string, value attr.Value, allowed []string) error {
+	switch name {
+	case "override":
+		b, ok := value.(basetypes.BoolValue)
+		if !ok {
+			return errors.New("must be a bool")
+		}
+		o.with_override = b.ValueBool()
+		return nil
+
+	case "depth":
+		n, ok := value.(basetypes.NumberValue)
+		if !ok {
+			return errors.New("must be a number")
+		}
+		depth, accuracy := n.ValueBigFloat().Int64()
+		if accuracy != big.Exact || depth < 0 {
+			return errors.New("must be a whole number of levels, or 0 for no limit")
+		}
+		o.depth = depth
+		return nil
+	}
+
+	s, ok := value.(basetypes.StringValue)
+	if !ok {
+		return errors.New("must be a string")
+	}
+	option := s.ValueString()
+
+	if allowed != nil && !slices.Contains(allowed, option) {
+		return fmt.Errorf("must be one of %s, got %q", quotedList(allowed), option)
+	}
+	if option == "" {
+		return errors.New("must not be empty")
+	}
+
+	switch name {
+	case "nulls":
+		o.with_null_override = option == "override"
+	case "empty":
+		o.with_empty_override = option == "override"
+	case "lists":
+		o.with_append, o.with_union, o.with_zip, o.with_prepend = false, false, false, false
+		if option != "replace" {
+			o.setString(option)
+		}
+	case "merge_lists_by":
+		o.merge_lists_by = option
+	case "knockout":
+		o.knockout_prefix = option
+	case "types":
+		o.type_conflicts = option
+		if option == "replace" {
+			o.type_conflicts = ""
+		}
+	case "conflicts":
+		o.no_conflict = option == "error"
+	}
+
+	return nil
+}
+
+// controlArgument returns the value of an argument of the form
+// { "<key>" = ... }, or false if the argument is ordinary data.
+func controlArgument(v attr.Value, key string) (attr.Value, bool) {
+	var attrs map[string]attr.Value
+
+	switch vv := v.(type) {
+	case basetypes.ObjectValue:
+		attrs = vv.Attributes()
+	case basetypes.MapValue:
+		attrs = vv.Elements()
+	}
+
+	value, ok := attrs[key]
+	if !ok || len(attrs) != 1 {
+		return nil, false
+	}
+
+	if dv, ok := value.(basetypes.DynamicValue); ok && !dv.IsNull() && !dv.IsUnknown() {
+		return dv.UnderlyingValue(), true
+	}
+
+	return value, true
+}
+
+// quotedList formats values as e.g. `"a", "b" or "c"`.
+func quotedList(values []string) string {
+	quoted := make([]string, len(values))
+	for i, v := range values {
+		quoted[i] = fmt.Sprintf("%q", v)
+	}
+	if len(quoted) < 2 {
+		return strings.Join(quoted, "")
+	}
+	return strings.Join(quoted[:len(quoted)-1], ", ") + " or " + quoted[len(quoted)-1]
+}
+
+// optionNames are the option strings, for suggesting a correction to one
+// that is not recognised. Those that take a value are given by their prefix.
+var optionNames = []string{
+	"override", "replace", "no_override", "no_null_override", "no_empty_override",
+	"append", "append_lists", "union", "union_lists", "zip", "zip_lists",
+	"prepend", "prepend_lists", "prepend_union", "merge_lists_by:", "knockout:",
+	"no_conflict", "strict", "strict_types", "keep_types",
+	"sequential", "normalize", "normalize_lists", typeConstraintPrefix,
+}
+
+// unrecognised returns the error for a string of the given kind that is not
+// one of names, suggesting the name it is most likely a misspelling of.
+func unrecognised(kind, s string, names []string) error {
+	// for a name that takes a value, only its prefix can be misspelt
+	prefix, value, found := strings.Cut(s, ":")
+	if found {
+		prefix += ":"
+	}
+
+	if suggestion, ok := didYouMean(prefix, names); ok && suggestion+value != s {
+		return fmt.Errorf("unrecognised %s %q, did you mean %q?", kind, s, suggestion+value)
+	}
+	return fmt.Errorf("unrecognised %s %q", kind, s)
+}
+
+// didYouMean returns the candidate closest to s, if any is close enough for
+// s to be a likely misspelling of it.
+func didYouMean(s string, candidates []string) (string, bool) {
+	best, bestDistance := "", max(1, len(s)/4)+1
+	for _, candidate := range candidates {
+		if d := editDistance(s, candidate); d < bestDistance {
+			best, bestDistance = candidate, d
+		}
+	}
+	return best, best != ""
+}
+
+// editDistance counts the insertions, deletions, substitutions and swaps of
+// adjacent bytes needed to turn a into b.
+func editDistance(a, b string) int {
+	// rows i-2, i-1 and i of the distances between prefixes of a and b
+	prev2, prev, row := make([]int, len(b)+1), make([]int, len(b)+1), make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		row[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			row[j] = min(prev[j]+1, row[j-1]+1, prev[j-1]+cost)
+			if i > 1 && j > 1 && a[i-1] == b[j-2] && a[i-2] == b[j-1] {
+				row[j] = min(row[j], prev2[j-2]+1)
+			}
+		}
+		prev2, prev, row = prev, row, prev2
+	}
+
+	return prev[len(b)]
+}
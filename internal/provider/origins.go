@@ -0,0 +1,180 @@
+// Copyright (c) Robin Breathe and contributors
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/isometry/terraform-provider-deepmerge/internal/helpers"
+)
+
+// origins records, for each path of the merged value, the layer (the index
+// among the maps being merged) that supplied it. A value without an entry of
+// its own inherits the origin of its nearest recorded ancestor. A nil origins
+// records nothing.
+//
+// Origins are held in a tree following the paths, so that forgetting or
+// listing the origins below a path only visits that subtree.
+type origins struct {
+	arguments []helpers.Argument // the argument supplying each layer
+	root      *origin
+}
+
+// origin is the node of an origins tree for one path.
+type origin struct {
+	layer    int64
+	recorded bool // whether layer is recorded for this path itself
+	children map[string]*origin
+}
+
+func newOrigins(arguments []helpers.Argument) *origins {
+	return &origins{arguments: arguments, root: &origin{}}
+}
+
+// lookup returns the node for path, creating it and its ancestors if create
+// is set, or nil if there is none.
+func (o *origins) lookup(path []string, create bool) *origin {
+	n := o.root
+	for _, segment := range path {
+		child := n.children[segment]
+		if child == nil {
+			if !create {
+				return nil
+			}
+			if n.children == nil {
+				n.children = make(map[string]*origin)
+			}
+			child = &origin{}
+			n.children[segment] = child
+		}
+		n = child
+	}
+	return n
+}
+
+// set records that the value at path, including everything below it, was
+// supplied by layer.
+func (o *origins) set(path []string, layer int64) {
+	if o == nil {
+		return
+	}
+	*o.lookup(path, true) = origin{layer: layer, recorded: true}
+}
+
+// remove forgets the value at path and everything below it.
+func (o *origins) remove(path []string) {
+	if o == nil {
+		return
+	}
+	if len(path) == 0 {
+		*o.root = origin{}
+		return
+	}
+	if parent := o.lookup(path[:len(path)-1], false); parent != nil {
+		delete(parent.children, path[len(path)-1])
+	}
+}
+
+// get returns the layer that supplied the value at path.
+func (o *origins) get(path []string) (int64, bool) {
+	if o == nil {
+		return 0, false
+	}
+
+	layer, ok := o.root.layer, o.root.recorded
+	n := o.root
+	for _, segment := range path {
+		if n = n.children[segment]; n == nil {
+			break
+		}
+		if n.recorded {
+			layer, ok = n.layer, true
+		}
+	}
+	return layer, ok
+}
+
+// sources returns every layer that contributed to the value at path, in
+// ascending order.
+func (o *origins) sources(path []string) []int64 {
+	var layers []int64
+	if layer, ok := o.get(path); ok {
+		layers = append(layers, layer)
+	}
+
+	var below func(n *origin)
+	below = func(n *origin) {
+		for _, child := range n.children {
+			if child.recorded {
+				layers = append(layers, child.layer)
+			}
+			below(child)
+		}
+	}
+	if n := o.lookup(path, false); n != nil {
+		below(n)
+	}
+
+	slices.Sort(layers)
+	return slices.Compact(layers)
+}
+
+// relist rearranges the elements of the list at path: element i of the new
+// list takes the origins of element from[i] of the old list, or, if from[i]
+// is negative, is recorded as supplied by layer. Elements of the old list not
+// mentioned in from are forgotten.
+func (o *origins) relist(path []string, from []int, layer int64) {
+	if o == nil {
+		return
+	}
+
+	n := o.lookup(path, true)
+	old := n.children
+	n.children = make(map[string]*origin, len(from))
+	for i, j := range from {
+		if j < 0 {
+			n.children[strconv.Itoa(i)] = &origin{layer: layer, recorded: true}
+		} else if child := old[strconv.Itoa(j)]; child != nil {
+			n.children[strconv.Itoa(i)] = child
+		}
+	}
+}
+
+// keptIndices returns the indices of a list of length n, less those deleted,
+// in the form expected by relist.
+func keptIndices(n int, deleted map[int]bool) []int {
+	from := make([]int, 0, n)
+	for i := 0; i < n; i++ {
+		if !deleted[i] {
+			from = append(from, i)
+		}
+	}
+	return from
+}
+
+// describeArguments lists the arguments supplying the given layers as they
+// are named in error messages, e.g. "arguments 1, 2 and 4".
+func (o *origins) describeArguments(layers []int64) string {
+	names := make([]string, len(layers))
+	plain := true
+	for i, layer := range layers {
+		names[i] = o.arguments[layer].String()
+		plain = plain && o.arguments[layer].Element < 0
+	}
+
+	if plain && len(names) > 1 {
+		for i := range names {
+			names[i] = strings.TrimPrefix(names[i], "argument ")
+		}
+		return fmt.Sprintf("arguments %s and %s", strings.Join(names[:len(names)-1], ", "), names[len(names)-1])
+	}
+
+	if len(names) > 1 {
+		return fmt.Sprintf("%s and %s", strings.Join(names[:len(names)-1], ", "), names[len(names)-1])
+	}
+	return strings.Join(names, "")
+}
@@ -0,0 +1,141 @@
+// Copyright (c) Robin Breathe and contributors
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/types/basetypes"
+)
+
+// pathRulesKey is the single attribute of an argument that carries per-path
+// merge strategies rather than data to be merged.
+const pathRulesKey = "$rules"
+
+// pathRule applies a merge strategy to every value whose path matches pattern.
+// Each pattern segment is either a literal key (or list index) or "*".
+type pathRule struct {
+	pattern  []string
+	strategy string
+}
+
+// pathRules are ordered most specific first, so the first match wins.
+type pathRules []pathRule
+
+// parsePathRules validates a map of path patterns to strategies, adding each
+// to rules. Later definitions of the same pattern replace earlier ones.
+func parsePathRules(v attr.Value, rules map[string]string) error {
+	var elems map[string]attr.Value
+
+	switch vv := v.(type) {
+	case basetypes.ObjectValue:
+		elems = vv.Attributes()
+	case basetypes.MapValue:
+		elems = vv.Elements()
+	default:
+		return fmt.Errorf("%s must be a map of path patterns to strategies", pathRulesKey)
+	}
+
+	for pattern, value := range elems {
+		s, ok := value.(basetypes.StringValue)
+		if !ok || s.IsNull() || s.IsUnknown() {
+			return fmt.Errorf("%s: strategy for %q must be a known string", pathRulesKey, pattern)
+		}
+
+		strategy, err := canonicalStrategy(s.ValueString())
+		if err != nil {
+			return fmt.Errorf("%s: %q: %w", pathRulesKey, pattern, err)
+		}
+
+		rules[pattern] = strategy
+	}
+
+	return nil
+}
+
+// canonicalStrategy normalises the aliases accepted for a path strategy.
+func canonicalStrategy(strategy string) (string, error) {
+	switch strategy {
+	case "override", "replace":
+		return "replace", nil
+	case "no_override":
+		return "no_override", nil
+	case "append", "append_lists":
+		return "append", nil
+	case "union", "union_lists":
+		return "union", nil
+	case "zip", "zip_lists":
+		return "zip", nil
+	case "prepend", "prepend_lists":
+		return "prepend", nil
+	case "prepend_union":
+		return "prepend_union", nil
+	}
+
+	if key, ok := strings.CutPrefix(strategy, "merge_lists_by:"); ok && key != "" {
+		return strategy, nil
+	}
+
+	return "", unrecognised("strategy", strategy, strategyNames)
+}
+
+// strategyNames are the path strategies, for suggesting a correction to one
+// that is not recognised.
+var strategyNames = []string{
+	"override", "replace", "no_override", "append", "append_lists", "union", "union_lists",
+	"zip", "zip_lists", "prepend", "prepend_lists", "prepend_union", "merge_lists_by:",
+}
+
+// newPathRules orders the collected rules so that patterns with fewer
+// wildcards take precedence.
+func newPathRules(rules map[string]string) pathRules {
+	r := make(pathRules, 0, len(rules))
+	for pattern, strategy := range rules {
+		r = append(r, pathRule{pattern: strings.Split(pattern, "."), strategy: strategy})
+	}
+
+	wildcards := func(p []string) (n int) {
+		for _, s := range p {
+			if s == "*" {
+				n++
+			}
+		}
+		return n
+	}
+
+	sort.Slice(r, func(i, j int) bool {
+		if wi, wj := wildcards(r[i].pattern), wildcards(r[j].pattern); wi != wj {
+			return wi < wj
+		}
+		return strings.Join(r[i].pattern, ".") < strings.Join(r[j].pattern, ".")
+	})
+
+	return r
+}
+
+// match returns the strategy of the first rule matching path.
+func (r pathRules) match(path []string) string {
+	for _, rule := range r {
+		if len(rule.pattern) != len(path) {
+			continue
+		}
+
+		matched := true
+		for i, segment := range rule.pattern {
+			if segment != "*" && segment != path[i] {
+				matched = false
+				break
+			}
+		}
+
+		if matched {
+			return rule.strategy
+		}
+	}
+
+	return ""
+}
@@ -51,6 +51,7 @@ func (p *DeepmergeProvider) DataSources(ctx context.Context) []func() datasource
 func (p *DeepmergeProvider) Functions(ctx context.Context) []func() function.Function {
 	return []func() function.Function{
 		NewMergoFunction,
+		NewMergoProvenanceFunction,
 	}
 }
 
@@ -0,0 +1,114 @@
+// Copyright (c) Robin Breathe and contributors
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/ext/typeexpr"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/convert"
+
+	"github.com/isometry/terraform-provider-deepmerge/internal/helpers"
+)
+
+// typeConstraintPrefix introduces an option giving the type to which the
+// merged value is converted, written as for a variable's type, e.g.
+// "type:map(object({ name = string, port = optional(number, 80) }))".
+const typeConstraintPrefix = "type:"
+
+// typeConstraint is a parsed type constraint, along with the defaults for
+// any optional attributes.
+type typeConstraint struct {
+	source   string
+	ty       cty.Type
+	defaults *typeexpr.Defaults
+}
+
+func parseTypeConstraint(source string) (*typeConstraint, error) {
+	expr, diags := hclsyntax.ParseExpression([]byte(source), "type", hcl.InitialPos)
+	if !diags.HasErrors() {
+		var ty cty.Type
+		var defaults *typeexpr.Defaults
+		if ty, defaults, diags = typeexpr.TypeConstraintWithDefaults(expr); !diags.HasErrors() {
+			return &typeConstraint{source: source, ty: ty, defaults: defaults}, nil
+		}
+	}
+
+	detail := diags[0].Summary
+	if diags[0].Detail != "" {
+		detail = diags[0].Detail
+	}
+	return nil, fmt.Errorf("invalid type constraint %q: %s", source, detail)
+}
+
+// convert converts v to the constrained type, filling in defaults for
+// missing optional attributes as Terraform does for a variable.
+func (c *typeConstraint) convert(ctx context.Context, v attr.Value) (attr.Value, error) {
+	cv, err := helpers.ToCtyValue(ctx, v)
+	if err != nil {
+		return nil, err
+	}
+
+	if c.defaults != nil {
+		cv = c.defaults.Apply(cv)
+	}
+
+	converted, err := convert.Convert(cv, c.ty)
+	if err != nil {
+		var pathErr cty.PathError
+		if errors.As(err, &pathErr) && len(pathErr.Path) > 0 {
+			return nil, fmt.Errorf("result does not match type %s at %s: %s", c.source, formatCtyPath(pathErr.Path), pathErr.Error())
+		}
+		return nil, fmt.Errorf("result does not match type %s: %s", c.source, err)
+	}
+
+	return helpers.FromCtyValue(ctx, converted)
+}
+
+// unknown returns an unknown value of the constrained type, or of no
+// particular type if the constraint leaves part of it open, as any does.
+func (c *typeConstraint) unknown(ctx context.Context) types.Dynamic {
+	ty := c.ty.WithoutOptionalAttributesDeep()
+	if ty.HasDynamicTypes() {
+		return types.DynamicUnknown()
+	}
+
+	v, err := helpers.FromCtyValue(ctx, cty.UnknownVal(ty))
+	if err != nil {
+		return types.DynamicUnknown()
+	}
+	return types.DynamicValue(v)
+}
+
+// formatCtyPath formats a path within a value as elsewhere in error messages,
+// e.g. services["web"].ports[0].
+func formatCtyPath(cp cty.Path) string {
+	p := path.Empty()
+	for _, step := range cp {
+		switch s := step.(type) {
+		case cty.GetAttrStep:
+			p = p.AtName(s.Name)
+		case cty.IndexStep:
+			switch {
+			case s.Key.Type() == cty.String && s.Key.IsKnown() && !s.Key.IsNull():
+				p = p.AtMapKey(s.Key.AsString())
+			case s.Key.Type() == cty.Number && s.Key.IsKnown() && !s.Key.IsNull():
+				i, _ := s.Key.AsBigFloat().Int64()
+				p = p.AtListIndex(int(i))
+			default:
+				// set elements have no key of their own
+				p = p.AtSetValue(types.DynamicNull())
+			}
+		}
+	}
+	return helpers.FormatPath(p)
+}
@@ -0,0 +1,296 @@
+// Copyright (c) Robin Breathe and contributors
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"slices"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-framework/types/basetypes"
+
+	"github.com/isometry/terraform-provider-deepmerge/internal/helpers"
+)
+
+// An unknown value may turn out to be any value of its type, including null,
+// so merging with one gives a known result only where every possible value
+// would give the same result. Otherwise the result is unknown, as is the
+// whole of a list whose length or order depends on an unknown value.
+
+// kindOfType classifies the values of a type, or returns kindUnknown for the
+// dynamic type, whose values may be of any kind.
+func kindOfType(t attr.Type) kind {
+	switch t.(type) {
+	case basetypes.DynamicType:
+		return kindUnknown
+	case basetypes.ObjectType:
+		return kindObject
+	case basetypes.MapType:
+		return kindMap
+	case basetypes.TupleType:
+		return kindTuple
+	case basetypes.ListType:
+		return kindList
+	case basetypes.SetType:
+		return kindSet
+	default:
+		return kindScalar
+	}
+}
+
+// mergeUnknown merges src into dst where either is unknown. A known dst is
+// kept if no value of src's type could change it, and a known src replaces
+// dst if it would whatever dst turns out to be; otherwise the result is
+// unknown, and of unknown type unless every outcome has the same type.
+func (m *merger) mergeUnknown(ctx context.Context, path []string, dst *node, src attr.Value) (*node, bool, error) {
+	if kindOf(src) == kindUnknown {
+		if m.keeps(ctx, path, dst, src.Type(ctx)) {
+			if m.tracing {
+				m.trace(ctx, "Unknown ignored", path, map[string]any{"kept": dst.kindName()})
+			}
+			return nil, false, nil
+		}
+		if m.tracing {
+			m.trace(ctx, "Unknown propagated", path, nil)
+		}
+		m.origins.set(path, m.layer)
+		if dst.kind() == kindNull || !dst.open && sameScalarType(ctx, dst.value, src) {
+			return leaf(src), true, nil
+		}
+		return leaf(types.DynamicUnknown()), true, nil
+	}
+
+	if m.overrides(ctx, path, dst.value.Type(ctx), src) {
+		return m.replaced(ctx, path, src, true)
+	}
+	if kindOf(src) == kindNull || sameScalarType(ctx, dst.value, src) {
+		if m.tracing {
+			m.trace(ctx, "Unknown kept", path, map[string]any{"ignored": leaf(src).kindName()})
+		}
+		return nil, false, nil
+	}
+	if m.tracing {
+		m.trace(ctx, "Unknown propagated", path, map[string]any{"reason": "the result depends on the earlier unknown"})
+	}
+	return leaf(types.DynamicUnknown()), true, nil
+}
+
+// sameScalarType reports whether a and b are scalars of the same type, so
+// that merging them gives a value of that type.
+func sameScalarType(ctx context.Context, a, b attr.Value) bool {
+	ta, tb := unwrap(a).Type(ctx), unwrap(b).Type(ctx)
+	return kindOfType(ta) == kindScalar && ta.Equal(tb)
+}
+
+// keeps reports whether merging any value of type t, or null, into the known
+// dst at path would leave dst exactly as it is.
+func (m *merger) keeps(ctx context.Context, path []string, dst *node, t attr.Type) bool {
+	rule, override, deep := m.modeAt(path)
+	dstKind, srcKind := dst.kind(), kindOfType(t)
+
+	switch {
+	case dstKind == kindNull || dstKind == kindUnknown || override || m.fillsZero(rule, dst):
+		return false
+
+	case dstKind.isMap() && deep && (srcKind.isMap() || srcKind == kindUnknown):
+		// only an object's keys are known, and merging it into a map would
+		// make an object of the result
+		ot, ok := t.(basetypes.ObjectType)
+		if !ok || dstKind != kindObject || m.with_directives {
+			return false
+		}
+		for key, at := range ot.AttrTypes {
+			if _, ok := m.knockedOut(key); ok || !m.keeps(ctx, append(slices.Clip(path), key), dst.child(key), at) {
+				return false
+			}
+		}
+		return true
+
+	case dstKind.isList() && deep && (srcKind.isList() || srcKind == kindUnknown):
+		return m.listStrategy(rule, dst, types.DynamicUnknown()) == ""
+
+	default:
+		// a scalar, or a value of another kind, is never merged into
+		return true
+	}
+}
+
+// overrides reports whether merging the known src into any value of type t,
+// or null, at path would give src.
+func (m *merger) overrides(ctx context.Context, path []string, t attr.Type, src attr.Value) bool {
+	rule, override, deep := m.modeAt(path)
+	src = unwrap(src)
+	srcKind, dstKind := kindOf(src), kindOfType(t)
+
+	switch {
+	case srcKind == kindUnknown, rule == "replace":
+		return true
+
+	case srcKind == kindNull:
+		return m.with_null_override && override
+
+	case !override, !m.with_empty_override && isEmpty(src), m.knockout_prefix != "", m.with_directives:
+		return false
+
+	case srcKind.isMap() && deep && (dstKind.isMap() || dstKind == kindUnknown):
+		// only an object's keys are known, and merging a map into it would
+		// make an object of the result
+		ot, ok := t.(basetypes.ObjectType)
+		if !ok || srcKind != kindObject {
+			return false
+		}
+		attrs := attributes(src)
+		for key, at := range ot.AttrTypes {
+			v, ok := attrs[key]
+			if !ok || !m.overrides(ctx, append(slices.Clip(path), key), at, v) {
+				return false
+			}
+		}
+		return true
+
+	case srcKind.isList() && deep && (dstKind.isList() || dstKind == kindUnknown):
+		return m.listStrategy(rule, leaf(types.DynamicUnknown()), src) == ""
+
+	case srcKind == kindScalar && m.type_conflicts == "keep":
+		// a map or list would be kept
+		return dstKind == kindScalar
+
+	default:
+		return true
+	}
+}
+
+// child returns the node for key in a map node, or nil if there is none.
+func (n *node) child(key string) *node {
+	if n == nil {
+		return nil
+	}
+	if n.open {
+		return n.keys[key]
+	}
+	if v, ok := attributes(n.value)[key]; ok {
+		return leaf(v)
+	}
+	return nil
+}
+
+// distinguishable reports whether it is known which of values are equal: no
+// value that is wholly or partly unknown may turn out to equal another.
+func distinguishable(ctx context.Context, values []attr.Value) bool {
+	for i, v := range values {
+		if !containsUnknown(v) {
+			continue
+		}
+		for j, other := range values {
+			if i != j && mayEqual(ctx, v, other) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// containsUnknown reports whether v is wholly or partly unknown.
+func containsUnknown(v attr.Value) bool {
+	switch k := kindOf(v); {
+	case k == kindUnknown:
+		return true
+	case k.isMap():
+		for _, elem := range attributes(v) {
+			if containsUnknown(elem) {
+				return true
+			}
+		}
+		return false
+	case k.isList():
+		elems, _ := sequenceElements(unwrap(v))
+		return slices.ContainsFunc(elems, containsUnknown)
+	default:
+		return false
+	}
+}
+
+// mayEqual reports whether a and b could turn out to be equal, as compared
+// by equalValues, once any unknowns within them are known.
+func mayEqual(ctx context.Context, a, b attr.Value) bool {
+	a, b = unwrap(a), unwrap(b)
+	ka, kb := kindOf(a), kindOf(b)
+
+	switch {
+	case ka == kindUnknown || kb == kindUnknown:
+		if ka == kindNull || kb == kindNull {
+			return true
+		}
+		ta, tb := kindOfType(a.Type(ctx)), kindOfType(b.Type(ctx))
+		switch {
+		case ta == kindUnknown || tb == kindUnknown:
+			return true
+		case ta.isMap() || tb.isMap():
+			return ta.isMap() && tb.isMap()
+		case ta.isList() || tb.isList():
+			return ta.isList() && tb.isList()
+		default:
+			return a.Type(ctx).Equal(b.Type(ctx))
+		}
+
+	case ka.isMap() && kb.isMap():
+		x, y := attributes(a), attributes(b)
+		if len(x) != len(y) {
+			return false
+		}
+		for key, elem := range x {
+			other, ok := y[key]
+			if !ok || !mayEqual(ctx, elem, other) {
+				return false
+			}
+		}
+		return true
+
+	case ka.isList() && kb.isList():
+		x, _ := sequenceElements(a)
+		y, _ := sequenceElements(b)
+		return slices.EqualFunc(x, y, func(x, y attr.Value) bool { return mayEqual(ctx, x, y) })
+
+	default:
+		return equalValues(a, b)
+	}
+}
+
+// expandUnknown returns an object of unknown attributes standing in for the
+// unknown object v, so that its attributes can be merged one by one, or false
+// if v is not an object: the keys of an unknown map cannot be known.
+func expandUnknown(ctx context.Context, v attr.Value) (attr.Value, bool) {
+	ot, ok := v.Type(ctx).(basetypes.ObjectType)
+	if !ok {
+		return nil, false
+	}
+
+	attrs := make(map[string]attr.Value, len(ot.AttrTypes))
+	for key, t := range ot.AttrTypes {
+		attrs[key] = helpers.UnknownOf(t)
+	}
+	return types.ObjectValueMust(ot.AttrTypes, attrs), true
+}
+
+// mayBeArgument reports whether an unknown argument of type t may turn out to
+// be one that mergo accepts: an option string, a map or a list of maps.
+func mayBeArgument(t attr.Type) bool {
+	switch tt := t.(type) {
+	case basetypes.StringType:
+		return true
+	case basetypes.ListType:
+		return mayBeMap(tt.ElemType)
+	case basetypes.TupleType:
+		return !slices.ContainsFunc(tt.ElemTypes, func(t attr.Type) bool { return !mayBeMap(t) })
+	default:
+		return mayBeMap(t)
+	}
+}
+
+// mayBeMap reports whether a value of type t may turn out to be a map.
+func mayBeMap(t attr.Type) bool {
+	k := kindOfType(t)
+	return k.isMap() || k == kindUnknown
+}